@@ -0,0 +1,74 @@
+package bowl
+
+// Action tells Apply what to do with the value returned by the user function
+type Action int
+
+const (
+	// ACTION_KEEP leaves the key as it is
+	ACTION_KEEP Action = 0
+	// ACTION_SET stores the returned value, inserting the key if it does not exist yet
+	ACTION_SET Action = 1
+	// ACTION_DELETE removes the key, if it exists
+	ACTION_DELETE Action = 2
+)
+
+// Apply calls fn for each key, with its current value (or zero value, when `exists` is false),
+// then keeps, sets, or deletes the key based on the returned Action.
+// Everything happens in a single ordered pass, under a single lock,
+// so fn sees the result of the earlier keys in the same batch
+//
+// fn should not call back into the same Bowl, as the lock is being held.
+// All actions are decided first, then logged to the write-ahead log, if any, and only then applied.
+// So if the log fails, the error is returned and nothing is applied, see OpenBOWL
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) Apply(keys []k, fn func(key k, old v, exists bool) (v, Action)) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	ops := make([]batchOp[k, v], 0, len(keys))
+	for _, key := range keys {
+		old, exists := b.getPlannedItem(ops, key)
+		newValue, action := fn(key, old.Value, exists)
+		switch {
+		case action == ACTION_SET:
+			ops = append(ops, batchOp[k, v]{opType: OP_PUT, item: Item[k, v]{Key: key, Value: newValue}, index: len(ops)})
+		case action == ACTION_DELETE && exists:
+			ops = append(ops, batchOp[k, v]{opType: OP_DELETE, item: Item[k, v]{Key: key}, index: len(ops)})
+		}
+	}
+	return b.applyPlanned(ops)
+}
+
+// getPlannedItem returns the item of key as if ops, planned but not applied yet, were already applied.
+// As keys are ascending, only the last op can be on the same key
+//
+// Should only be called when Lock is held
+func (b *Bowl[k, v]) getPlannedItem(ops []batchOp[k, v], key k) (Item[k, v], bool) {
+	if len(ops) > 0 && b.cmp(ops[len(ops)-1].item.Key, key) == 0 {
+		last := ops[len(ops)-1]
+		return last.item, last.opType != OP_DELETE
+	}
+	return b.getItemForRead(key)
+}
+
+// applyPlanned logs then applies ops, which never fail by themselves, as they are decided under the same Lock
+//
+// Should only be called when Lock is held
+func (b *Bowl[k, v]) applyPlanned(ops []batchOp[k, v]) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	release, err := b.logOps(WAL_RECORD_BATCH, len(ops), func(i int) batchOp[k, v] { return ops[i] })
+	if err != nil {
+		return err
+	}
+	defer release()
+	b.structureVersion.Add(1)
+	b.applyOps(ops, make([]error, len(ops)))
+	return nil
+}
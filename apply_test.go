@@ -0,0 +1,145 @@
+package bowl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBowlApply(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 2, Value: 1})
+	}
+	b.Insert(ihs)
+
+	// increment existing, create missing odd keys, delete multiples of 10
+	keys := make([]int, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		keys = append(keys, i)
+	}
+	called := 0
+	b.Apply(keys, func(key int, old int, exists bool) (int, Action) {
+		called++
+		if exists != (key%2 == 0) {
+			t.Fatalf("Key %d should exist only if even, but instead we got exists: %v", key, exists)
+		}
+		if key%10 == 0 {
+			return 0, ACTION_DELETE
+		}
+		if key%7 == 0 {
+			return 12345, ACTION_KEEP
+		}
+		return old + 1, ACTION_SET
+	})
+	if called != 2000 {
+		t.Fatalf("fn should be called once per key, but instead it is called %d times", called)
+	}
+
+	res := b.Get(keys, math.MinInt)
+	for i, r := range res {
+		expected := 1
+		switch {
+		case i%10 == 0:
+			expected = math.MinInt
+		case i%7 == 0 && i%2 == 0:
+			expected = 1
+		case i%7 == 0:
+			expected = math.MinInt
+		case i%2 == 0:
+			expected = 2
+		}
+		if r != expected {
+			t.Fatalf("Key %d should be %d, but instead we got %d", i, expected, r)
+		}
+	}
+	checkBowlLinks(t, b)
+
+	// deleting everything, then setting again in the same kind of pass
+	b.Apply(keys, func(key int, old int, exists bool) (int, Action) {
+		return 0, ACTION_DELETE
+	})
+	count := 0
+	b.ScanAll(func(ih Item[int, int]) {
+		count++
+	})
+	if count != 0 {
+		t.Fatalf("Everything should be deleted, but instead we got %d data", count)
+	}
+	b.Apply(keys[:10], func(key int, old int, exists bool) (int, Action) {
+		if exists {
+			t.Fatalf("Key %d should no longer exist, but it is", key)
+		}
+		return key, ACTION_SET
+	})
+	res = b.Get(keys[:10], math.MinInt)
+	for i, r := range res {
+		if r != i {
+			t.Fatalf("Key %d should be set back, but instead we got %d", i, r)
+		}
+	}
+
+	// the same key more than once sees what the earlier ones did
+	seen := make([]int, 0, 4)
+	b.Apply([]int{1, 1, 1, 1}, func(key int, old int, exists bool) (int, Action) {
+		if !exists {
+			old = math.MinInt
+		}
+		seen = append(seen, old)
+		if len(seen) == 2 {
+			return 0, ACTION_DELETE
+		}
+		return 100, ACTION_SET
+	})
+	if seen[0] != 1 || seen[1] != 100 || seen[2] != math.MinInt || seen[3] != 100 {
+		t.Fatalf("Repeated key should see 1, 100, missing, then 100, but instead we got %v", seen)
+	}
+}
+
+func benchmarkBowlWithData(b *testing.B) (*Bowl[int, int], [][]int) {
+	bowl := NewBOWL[int, int](cmpTest)
+	batches := make([][]int, 0, 256)
+	for i := 0; i < 256; i++ {
+		ihs := make([]Item[int, int], 0, 1024)
+		keys := make([]int, 0, 1024)
+		for j := 0; j < 1024; j++ {
+			key := j*256 + i
+			ihs = append(ihs, Item[int, int]{Key: key, Value: key})
+			keys = append(keys, key)
+		}
+		bowl.Insert(ihs)
+		batches = append(batches, keys)
+	}
+	return bowl, batches
+}
+
+func BenchmarkBowlUpdate(b *testing.B) {
+	b.StopTimer()
+	bowl, batches := benchmarkBowlWithData(b)
+	ihs := make([][]Item[int, int], 0, len(batches))
+	for _, keys := range batches {
+		batch := make([]Item[int, int], 0, len(keys))
+		for _, key := range keys {
+			batch = append(batch, Item[int, int]{Key: key, Value: key + 1})
+		}
+		ihs = append(ihs, batch)
+	}
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		bowl.Update(ihs[i%len(ihs)])
+	}
+}
+
+func BenchmarkBowlApply(b *testing.B) {
+	b.StopTimer()
+	bowl, batches := benchmarkBowlWithData(b)
+	increment := func(key int, old int, exists bool) (int, Action) {
+		return old + 1, ACTION_SET
+	}
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		bowl.Apply(batches[i%len(batches)], increment)
+	}
+}
@@ -0,0 +1,136 @@
+package bowl
+
+import (
+	"fmt"
+)
+
+// BatchError is returned by the atomic batch operations,
+// telling which item made the whole batch fail
+type BatchError struct {
+	// Index is the position of the failing item in the given batch
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch failed at index %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// InsertAtomic inserts all items, or none of them.
+// If any item fails, e.g. with ErrKeyAlreadyExist, everything already inserted is rolled back,
+// and the returned *BatchError tells which item failed
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) InsertAtomic(ihs []Item[k, v]) error {
+	ops := make([]batchOp[k, v], len(ihs))
+	for i, ih := range ihs {
+		ops[i] = batchOp[k, v]{opType: OP_INSERT, item: ih, index: i}
+	}
+	return b.writeAtomic(ops)
+}
+
+// UpdateAtomic updates all items, or none of them.
+// If any item fails, e.g. with ErrDataNotFound, everything already updated is rolled back,
+// and the returned *BatchError tells which item failed
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) UpdateAtomic(ihs []Item[k, v]) error {
+	ops := make([]batchOp[k, v], len(ihs))
+	for i, ih := range ihs {
+		ops[i] = batchOp[k, v]{opType: OP_UPDATE, item: ih, index: i}
+	}
+	return b.writeAtomic(ops)
+}
+
+// DeleteAtomic deletes all keys, or none of them.
+// If any key fails, e.g. with ErrDataNotFound, everything already deleted is rolled back,
+// and the returned *BatchError tells which key failed
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) DeleteAtomic(keys []k) error {
+	ops := make([]batchOp[k, v], len(keys))
+	for i, key := range keys {
+		ops[i] = batchOp[k, v]{opType: OP_DELETE, item: Item[k, v]{Key: key}, index: i}
+	}
+	return b.writeAtomic(ops)
+}
+
+// WriteAtomic applies all operations in wb, or none of them.
+// Operations superseded by a later one on the same key are simply skipped.
+// If any applied operation fails, everything is rolled back,
+// and the returned *BatchError tells which operation failed, in the order they were added
+func (b *Bowl[k, v]) WriteAtomic(wb *WriteBatch[k, v]) error {
+	ops := wb.sortedOps(b.cmp, make([]error, wb.Len()))
+	return b.writeAtomic(ops)
+}
+
+func (b *Bowl[k, v]) writeAtomic(ops []batchOp[k, v]) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	release, err := b.logOps(WAL_RECORD_ATOMIC, len(ops), func(i int) batchOp[k, v] { return ops[i] })
+	if err != nil {
+		return err
+	}
+	defer release()
+	b.structureVersion.Add(1)
+
+	return b.applyOpsAtomically(ops)
+}
+
+// applyOpsAtomically applies already sorted ops like applyOps, but stops at the first failing op,
+// then restores every key touched before it
+//
+// Should only be called when Lock is held
+func (b *Bowl[k, v]) applyOpsAtomically(ops []batchOp[k, v]) error {
+	undo := make([]batchOp[k, v], 0, len(ops))
+
+	b.resetLatestPointingNodes()
+	currentNode := b.getNextNodeFromHead(ops[0].item.Key)
+
+	for _, op := range ops {
+		currentNode = b.getCorrectNode(op.item.Key, currentNode)
+		prev, existed := currentNode.getItem(op.item.Key)
+
+		var err error
+		currentNode, err = b.applyOp(currentNode, op)
+		if err != nil {
+			b.rollback(undo)
+			return &BatchError{Index: op.index, Err: err}
+		}
+
+		if existed {
+			undo = append(undo, batchOp[k, v]{opType: OP_PUT, item: prev, index: len(undo)})
+		} else {
+			undo = append(undo, batchOp[k, v]{opType: OP_DELETE, item: Item[k, v]{Key: op.item.Key}, index: len(undo)})
+		}
+	}
+	return nil
+}
+
+// rollback puts every key in undo back to the state before the batch.
+// Undo is ascending, so it can be applied in a single pass as well,
+// using only the first entry of each key, as that is the one before the batch touched it.
+// Node splits that already happened stay, which is fine as the content is what matters
+//
+// Should only be called when Lock is held
+func (b *Bowl[k, v]) rollback(undo []batchOp[k, v]) {
+	if len(undo) == 0 {
+		return
+	}
+	restore := undo[:1]
+	for _, op := range undo[1:] {
+		if b.cmp(op.item.Key, restore[len(restore)-1].item.Key) != 0 {
+			op.index = len(restore)
+			restore = append(restore, op)
+		}
+	}
+	b.applyOps(restore, make([]error, len(restore)))
+}
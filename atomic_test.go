@@ -0,0 +1,98 @@
+package bowl
+
+import (
+	"errors"
+	"testing"
+)
+
+func snapshotContent(b *Bowl[int, int]) []Item[int, int] {
+	content := make([]Item[int, int], 0)
+	b.ScanAll(func(ih Item[int, int]) {
+		content = append(content, ih)
+	})
+	return content
+}
+
+// checkSameContent compares keys and values only, Seq is given by the Bowl
+func checkSameContent(t *testing.T, stage string, expected, got []Item[int, int]) {
+	t.Helper()
+	if len(expected) != len(got) {
+		t.Fatalf("%s: content should have %d data, but instead we got %d", stage, len(expected), len(got))
+	}
+	for i := range expected {
+		if expected[i].Key != got[i].Key || expected[i].Value != got[i].Value {
+			t.Fatalf("%s: at iter %d it should be %v, but instead we got %v", stage, i, expected[i], got[i])
+		}
+	}
+}
+
+func TestBowlAtomicBatch(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 4, Value: i * 4})
+	}
+	if err := b.InsertAtomic(ihs); err != nil {
+		t.Fatalf("Inserting into empty Bowl should succeed, but instead we got %v", err)
+	}
+	before := snapshotContent(b)
+
+	// lots of inserts, forcing splits, then failing on the very last one
+	inserts := make([]Item[int, int], 0, 2001)
+	for i := 0; i < 2000; i++ {
+		inserts = append(inserts, Item[int, int]{Key: i*2 + 1, Value: 1})
+	}
+	inserts = append(inserts, Item[int, int]{Key: 3996, Value: 1})
+	err := b.InsertAtomic(inserts)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 2000 || !errors.Is(err, ErrKeyAlreadyExist) {
+		t.Fatalf("InsertAtomic should fail at index 2000 with ErrKeyAlreadyExist, but instead we got %v", err)
+	}
+	checkSameContent(t, "after failed InsertAtomic", before, snapshotContent(b))
+	checkBowlLinks(t, b)
+
+	updates := make([]Item[int, int], 0, 500)
+	for i := 0; i < 500; i++ {
+		updates = append(updates, Item[int, int]{Key: i * 4, Value: -1})
+	}
+	updates[400].Key = 1601
+	err = b.UpdateAtomic(updates)
+	if !errors.As(err, &batchErr) || batchErr.Index != 400 || !errors.Is(err, ErrDataNotFound) {
+		t.Fatalf("UpdateAtomic should fail at index 400 with ErrDataNotFound, but instead we got %v", err)
+	}
+	checkSameContent(t, "after failed UpdateAtomic", before, snapshotContent(b))
+
+	// deleting whole nodes, which get marked removal, before failing
+	deletes := make([]int, 0, 901)
+	for i := 0; i < 900; i++ {
+		deletes = append(deletes, i*4)
+	}
+	deletes = append(deletes, 3601)
+	err = b.DeleteAtomic(deletes)
+	if !errors.As(err, &batchErr) || batchErr.Index != 900 || !errors.Is(err, ErrDataNotFound) {
+		t.Fatalf("DeleteAtomic should fail at index 900 with ErrDataNotFound, but instead we got %v", err)
+	}
+	checkSameContent(t, "after failed DeleteAtomic", before, snapshotContent(b))
+	checkBowlLinks(t, b)
+
+	wb := NewWriteBatch[int, int]()
+	for i := 0; i < 1000; i++ {
+		wb.Delete(i * 4)
+		wb.Put(i*4+1, 1)
+	}
+	wb.Update(2, 2)
+	wb.Put(2, 2) // supersedes the failing update above
+	wb.Insert(8, 8)
+	err = b.WriteAtomic(wb)
+	if !errors.As(err, &batchErr) || batchErr.Index != 2002 || !errors.Is(err, ErrKeyAlreadyExist) {
+		t.Fatalf("WriteAtomic should fail at index 2002 with ErrKeyAlreadyExist, but instead we got %v", err)
+	}
+	checkSameContent(t, "after failed WriteAtomic", before, snapshotContent(b))
+
+	// and a successful one applies everything
+	if err := b.DeleteAtomic(deletes[:900]); err != nil {
+		t.Fatalf("DeleteAtomic should succeed, but instead we got %v", err)
+	}
+	checkSameContent(t, "after DeleteAtomic", before[900:], snapshotContent(b))
+	checkBowlLinks(t, b)
+}
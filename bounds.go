@@ -0,0 +1,73 @@
+package bowl
+
+// BoundType decides how a Bound treats its key
+type BoundType int
+
+const (
+	UNBOUNDED BoundType = 0
+	INCLUSIVE BoundType = 1
+	EXCLUSIVE BoundType = 2
+)
+
+// Bound is one endpoint of a range of keys
+type Bound[k comparable] struct {
+	Type BoundType
+	Key  k
+}
+
+// Unbounded returns a Bound that does not limit the range at all
+func Unbounded[k comparable]() Bound[k] {
+	return Bound[k]{Type: UNBOUNDED}
+}
+
+// Inclusive returns a Bound that includes `key` itself
+func Inclusive[k comparable](key k) Bound[k] {
+	return Bound[k]{Type: INCLUSIVE, Key: key}
+}
+
+// Exclusive returns a Bound that stops right before `key`
+func Exclusive[k comparable](key k) Bound[k] {
+	return Bound[k]{Type: EXCLUSIVE, Key: key}
+}
+
+// Bounds describes a range of keys, from Lower to Upper
+type Bounds[k comparable] struct {
+	Lower Bound[k]
+	Upper Bound[k]
+}
+
+// satisfiesLower checks whether key is not below the lower endpoint
+func (bs Bounds[k]) satisfiesLower(cmp Comparator[k], key k) bool {
+	switch bs.Lower.Type {
+	case INCLUSIVE:
+		return cmp(key, bs.Lower.Key) >= 0
+	case EXCLUSIVE:
+		return cmp(key, bs.Lower.Key) == 1
+	}
+	return true
+}
+
+// satisfiesUpper checks whether key is not above the upper endpoint
+func (bs Bounds[k]) satisfiesUpper(cmp Comparator[k], key k) bool {
+	switch bs.Upper.Type {
+	case INCLUSIVE:
+		return cmp(key, bs.Upper.Key) <= 0
+	case EXCLUSIVE:
+		return cmp(key, bs.Upper.Key) == -1
+	}
+	return true
+}
+
+// overlaps checks whether bs and other have any key in common
+func (bs Bounds[k]) overlaps(cmp Comparator[k], other Bounds[k]) bool {
+	return !startsAfter(cmp, bs.Lower, other.Upper) && !startsAfter(cmp, other.Lower, bs.Upper)
+}
+
+// startsAfter checks whether every key satisfying lower is above every key satisfying upper
+func startsAfter[k comparable](cmp Comparator[k], lower Bound[k], upper Bound[k]) bool {
+	if lower.Type == UNBOUNDED || upper.Type == UNBOUNDED {
+		return false
+	}
+	c := cmp(lower.Key, upper.Key)
+	return c == 1 || (c == 0 && (lower.Type == EXCLUSIVE || upper.Type == EXCLUSIVE))
+}
@@ -1,36 +1,30 @@
 package bowl
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
 	"sync"
+	"sync/atomic"
 )
 
 const (
-	MAX_HEIGHT int = 32
+	MAX_HEIGHT        int     = 32
+	LEVEL_PROBABILITY float64 = 0.5
 )
 
-var rnd = rand.New(rand.NewSource(rand.Int63()))
-
-// this function is not goroutine-safe
-func generateLevel(maxHeight int) int {
-	level := 1
-	for rnd.Float64() < 0.5 && level < maxHeight {
-		level++
-	}
-	return level
-}
-
-// Bowl is an unrolled skip list where every operation grabs single mutex,
+// Bowl is an unrolled skip list where every write grabs single mutex,
 // reducing concurrency possibility, but gain simplicity of development,
-// as the API can be set to be totally one-pass, even on reconnections
+// as the API can be set to be totally one-pass, even on reconnections.
+// Get, scans, and Cursor only take the read side of it, so they can run concurrently.
 //
-// Deletes are still deferred for next access. While actually not needed,
+// Deletes are still deferred for next write access. While actually not needed,
 // it makes the implementation uniform with the others.
+// Reads never unlink anything, they just skip the nodes marked for removal.
 //
-// It has `STRICT SERIALIZABLE` isolation level, as everything goes through a single mutex
+// It has `STRICT SERIALIZABLE` isolation level, as everything goes through a single RWMutex.
+// See LOCKING_PER_NODE for the finer grained alternative, and its weaker guarantee
 type Bowl[k comparable, v any] struct {
-	sync.Mutex
+	sync.RWMutex
 	head *Node[k, v]
 	// ch   <-chan int
 	cmp Comparator[k]
@@ -42,20 +36,84 @@ type Bowl[k comparable, v any] struct {
 	// we update this value on any traversals
 	// but can safely ignore them for any other operations
 	latestPointingNodes []*Node[k, v]
+
+	// structureVersion is bumped every time data may move between positions,
+	// i.e. on Insert and Delete. Cursor uses it to know its position is stale
+	structureVersion atomic.Uint64
+
+	// strictValidation checks batches are sorted without duplicates, see WithStrictValidation
+	strictValidation bool
+
+	// layout of the list, see WithNodeSize, WithMaxHeight, and WithSplitRatio
+	nodeSize   int
+	maxHeight  int
+	splitRatio float64
+
+	// levelGenerator is owned by this Bowl only, see WithLevelGenerator and WithSeed
+	levelGenerator LevelGenerator
+
+	lockingMode LockingMode
+	// towerMu guards the links above height 0 with LOCKING_PER_NODE,
+	// taken before any node latch
+	towerMu sync.RWMutex
+
+	// snapshots tells the nodes when their data is shared with a live Snapshot
+	snapshots *snapshotState
+
+	// versions gives the sequence numbers, and keeps older versions with WithMVCC
+	versions *versionState
+
+	// wal is the write-ahead log, only for a Bowl opened by OpenBOWL
+	wal *wal[k, v]
 }
 
-// NewBOWL creates our new empty BOWL, with given Comparator
-func NewBOWL[k comparable, v any](cmp Comparator[k]) *Bowl[k, v] {
+// NewBOWL creates our new empty BOWL, with given Comparator and options
+func NewBOWL[k comparable, v any](cmp Comparator[k], opts ...Option) *Bowl[k, v] {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// empty node for head, so can skip logic for removing head if empty
-	head := NewEmptyNode[k, v](MAX_HEIGHT, cmp)
+	snapshots := &snapshotState{}
+	head := newNode[k, v](o.maxHeight, o.nodeSize, o.splitRatio, cmp)
+	head.snapshots = snapshots
+	versions := &versionState{retain: o.mvcc}
+	head.versions = versions
 	// ch := RandomLevelGenerator(MAX_HEIGHT)
-	latestPointingNodes := make([]*Node[k, v], MAX_HEIGHT)
+	latestPointingNodes := make([]*Node[k, v], o.maxHeight)
+
+	return &Bowl[k, v]{
+		head:                head,
+		cmp:                 cmp,
+		latestPointingNodes: latestPointingNodes,
+		strictValidation:    o.strictValidation,
+		nodeSize:            o.nodeSize,
+		maxHeight:           o.maxHeight,
+		levelGenerator:      o.getLevelGenerator(),
+		snapshots:           snapshots,
+		versions:            versions,
+		splitRatio:          o.splitRatio,
+		lockingMode:         o.lockingMode,
+	}
+}
 
-	return &Bowl[k, v]{head: head, cmp: cmp, latestPointingNodes: latestPointingNodes}
+// generateLevel returns a random height for a new node, kept between 1 and maxHeight
+// even if a custom LevelGenerator goes outside it
+func (b *Bowl[k, v]) generateLevel() int {
+	return min(max(b.levelGenerator.Level(b.maxHeight), 1), b.maxHeight)
+}
+
+// createNode creates an empty node of this Bowl, with height h
+func (b *Bowl[k, v]) createNode(h int) *Node[k, v] {
+	n := newNode[k, v](h, b.nodeSize, b.splitRatio, b.cmp)
+	n.snapshots = b.snapshots
+	n.versions = b.versions
+	return n
 }
 
 func (b *Bowl[k, v]) resetLatestPointingNodes() {
-	for i := 0; i < MAX_HEIGHT; i++ {
+	for i := 0; i < b.maxHeight; i++ {
 		b.latestPointingNodes[i] = b.head
 	}
 }
@@ -68,21 +126,63 @@ func (b *Bowl[k, v]) setLatestPointingNodes(n *Node[k, v]) {
 
 // Get returns all values for the given keys
 //
-// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed.
+// With WithStrictValidation, an invalid batch gets notFoundDefaultValue for every key,
+// use TryGet to get the validation error as well
 func (b *Bowl[k, v]) Get(keys []k, notFoundDefaultValue v) []v {
+	result, err := b.TryGet(keys, notFoundDefaultValue)
+	if err != nil {
+		return fillValues(make([]v, len(keys)), notFoundDefaultValue)
+	}
+	return result
+}
+
+// TryGet returns all values for the given keys,
+// or the validation error when WithStrictValidation is used and the batch is invalid
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) TryGet(keys []k, notFoundDefaultValue v) ([]v, error) {
+	if err := b.validateKeys(keys); err != nil {
+		return nil, err
+	}
+	return b.getRLocked(keys, notFoundDefaultValue), nil
+}
+
+// getRLocked takes the read lock, then returns all values for the given keys
+func (b *Bowl[k, v]) getRLocked(keys []k, notFoundDefaultValue v) []v {
 	result := make([]v, len(keys))
+	if len(keys) == 0 {
+		return result
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+	b.get(keys, notFoundDefaultValue, result)
+	return result
+}
 
-	b.Lock()
-	defer b.Unlock()
+// get puts the values for the given keys into result
+//
+// Should only be called when RLock is held, and keys is not empty
+func (b *Bowl[k, v]) get(keys []k, notFoundDefaultValue v, result []v) {
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.getLatched(keys, notFoundDefaultValue, result)
+		return
+	}
 
-	currentNode := b.getNextNodeFromHead(keys[0])
+	currentNode := b.getNodeForRead(keys[0])
+	if currentNode == nil {
+		for i := range result {
+			result[i] = notFoundDefaultValue
+		}
+		return
+	}
 
 	for i, k := range keys {
-		currentNode = b.getCorrectNode(k, currentNode)
+		currentNode = b.getCorrectNodeForRead(k, currentNode)
 		v, _ := currentNode.Get(k, notFoundDefaultValue)
 		result[i] = v
 	}
-	return result
 }
 
 // Update updates ih[i].Value when mathing ih[i].Key found
@@ -90,16 +190,42 @@ func (b *Bowl[k, v]) Get(keys []k, notFoundDefaultValue v) []v {
 // Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
 func (b *Bowl[k, v]) Update(ihs []Item[k, v]) []error {
 	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err)
+	}
+	if len(ihs) == 0 {
+		return errs
+	}
 
-	b.Lock()
-	defer b.Unlock()
+	b.writeLock()
+	defer b.writeUnlock()
+	b.update(ihs, errs)
+	return errs
+}
+
+// update updates each item, putting the results into errs
+//
+// Should only be called when writeLock is held, and ihs is not empty
+func (b *Bowl[k, v]) update(ihs []Item[k, v], errs []error) {
+	release, err := b.logOps(WAL_RECORD_BATCH, len(ihs), func(i int) batchOp[k, v] {
+		return batchOp[k, v]{opType: OP_UPDATE, item: ihs[i]}
+	})
+	if err != nil {
+		fillErrors(errs, err)
+		return
+	}
+	defer release()
+
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.updateLatched(ihs, errs)
+		return
+	}
 
 	currentNode := b.getNextNodeFromHead(ihs[0].Key)
 	for i, ih := range ihs {
 		currentNode = b.getCorrectNodeFromItemHandle(ih, currentNode)
 		errs[i] = currentNode.Update(ih)
 	}
-	return errs
 }
 
 // Delete removes all matching keys
@@ -107,9 +233,37 @@ func (b *Bowl[k, v]) Update(ihs []Item[k, v]) []error {
 // Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
 func (b *Bowl[k, v]) Delete(keys []k) []error {
 	errs := make([]error, len(keys))
+	if err := b.validateKeys(keys); err != nil {
+		return fillErrors(errs, err)
+	}
+	if len(keys) == 0 {
+		return errs
+	}
+
+	b.writeLock()
+	defer b.writeUnlock()
+	b.delete(keys, errs)
+	return errs
+}
 
-	b.Lock()
-	defer b.Unlock()
+// delete removes each key, putting the results into errs
+//
+// Should only be called when writeLock is held, and keys is not empty
+func (b *Bowl[k, v]) delete(keys []k, errs []error) {
+	release, err := b.logOps(WAL_RECORD_BATCH, len(keys), func(i int) batchOp[k, v] {
+		return batchOp[k, v]{opType: OP_DELETE, item: Item[k, v]{Key: keys[i]}}
+	})
+	if err != nil {
+		fillErrors(errs, err)
+		return
+	}
+	defer release()
+
+	b.structureVersion.Add(1)
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.deleteLatched(keys, errs)
+		return
+	}
 
 	currentNode := b.getNextNodeFromHead(keys[0])
 
@@ -120,7 +274,6 @@ func (b *Bowl[k, v]) Delete(keys []k) []error {
 			currentNode.MarkRemoval()
 		}
 	}
-	return errs
 }
 
 func (b *Bowl[k, v]) insertFastPathConnectNewNodeFromCurrent(
@@ -129,12 +282,20 @@ func (b *Bowl[k, v]) insertFastPathConnectNewNodeFromCurrent(
 		n, _ := currentNode.GetNextNodeAt(j)
 		newNode.ConnectNode(j, n)
 		currentNode.ConnectNode(j, newNode)
+		if j == 0 && n != nil {
+			n.ConnectPrevNode(newNode)
+		}
 	}
+	newNode.ConnectPrevNode(currentNode)
 }
 
+// connectUntil links targetNode right after latestPointingNodes[h],
+// for every h in `fromHeight` down to `toHeight`, inclusive
 func (b *Bowl[k, v]) connectUntil(
 	targetNode *Node[k, v], fromHeight, toHeight int) {
 	for h := fromHeight; h >= toHeight; h-- {
+		next, _ := b.latestPointingNodes[h].GetNextNodeAt(h)
+		targetNode.ConnectNode(h, next)
 		err := b.latestPointingNodes[h].ConnectNode(h, targetNode)
 		if err != nil {
 			panic(fmt.Sprintf("Should be no error in `connectUntil` function, means something is broken: %v", err))
@@ -147,9 +308,37 @@ func (b *Bowl[k, v]) connectUntil(
 // Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
 func (b *Bowl[k, v]) Insert(ihs []Item[k, v]) []error {
 	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err)
+	}
+	if len(ihs) == 0 {
+		return errs
+	}
+
+	b.writeLock()
+	defer b.writeUnlock()
+	b.insert(ihs, errs)
+	return errs
+}
+
+// insert inserts each item, putting the results into errs
+//
+// Should only be called when writeLock is held, and ihs is not empty
+func (b *Bowl[k, v]) insert(ihs []Item[k, v], errs []error) {
+	release, err := b.logOps(WAL_RECORD_BATCH, len(ihs), func(i int) batchOp[k, v] {
+		return batchOp[k, v]{opType: OP_INSERT, item: ihs[i]}
+	})
+	if err != nil {
+		fillErrors(errs, err)
+		return
+	}
+	defer release()
 
-	b.Lock()
-	defer b.Unlock()
+	b.structureVersion.Add(1)
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.insertLatched(ihs, errs)
+		return
+	}
 
 	b.resetLatestPointingNodes()
 	currentNode := b.getNextNodeFromHead(ihs[0].Key)
@@ -158,201 +347,424 @@ func (b *Bowl[k, v]) Insert(ihs []Item[k, v]) []error {
 		currentNode = b.getCorrectNodeFromItemHandle(ih, currentNode)
 		err := currentNode.Insert(ih)
 		if err != nil && err == ErrNodeIsFull {
-			newHeight := generateLevel(MAX_HEIGHT)
-			newNode := currentNode.SplitIntoNewNode(newHeight)
-
-			minHeight := newHeight
-			if minHeight > currentNode.GetHeight() {
-				minHeight = currentNode.GetHeight()
-			}
-			b.insertFastPathConnectNewNodeFromCurrent(currentNode, newNode, minHeight)
-			b.setLatestPointingNodes(currentNode)
-			if newHeight > currentNode.GetHeight() {
-				b.connectUntil(newNode, newHeight, currentNode.GetHeight())
-			}
-
-			if ok, _ := currentNode.CheckKeyStrictlyLessThanMax(ih.Key); ok {
-				err = currentNode.Insert(ih)
-			} else {
-				err = newNode.Insert(ih)
-				currentNode = newNode
-				b.setLatestPointingNodes(currentNode)
-			}
+			currentNode = b.splitForKey(currentNode, ih.Key)
+			err = currentNode.Insert(ih)
 			if err != nil {
 				panic(fmt.Sprintf("Should be no error here, means something is broken: %v", err))
 			}
 		}
 		errs[i] = err
 	}
-	return errs
 }
 
-func (b *Bowl[k, v]) scanNextNodeNotMarkedRemoval(
-	prev, next *Node[k, v]) (bool, *Node[k, v]) {
-	for next.MarkedRemoval() {
-		afterNext, _ := next.GetNextNodeAt(0)
-		if afterNext == nil {
-			return false, next
+// Upsert inserts each item, or replaces the value when the key already exists,
+// in a single pass. The result tells which one happened for each item.
+// If the write-ahead log fails, nothing is applied and every result is UPSERT_FAILED,
+// use TryUpsert to get the error as well
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) Upsert(ihs []Item[k, v]) []UpsertResult {
+	results, err := b.TryUpsert(ihs)
+	if err != nil {
+		return fillValues(make([]UpsertResult, len(ihs)), UPSERT_FAILED)
+	}
+	return results
+}
+
+// TryUpsert is just like Upsert, but returns the failure of the write-ahead log, if any, see OpenBOWL.
+// On failure, nothing is applied
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) TryUpsert(ihs []Item[k, v]) ([]UpsertResult, error) {
+	results := make([]UpsertResult, len(ihs))
+	if len(ihs) == 0 {
+		return results, nil
+	}
+
+	b.writeLock()
+	defer b.writeUnlock()
+	release, err := b.logOps(WAL_RECORD_BATCH, len(ihs), func(i int) batchOp[k, v] {
+		return batchOp[k, v]{opType: OP_PUT, item: ihs[i]}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	b.structureVersion.Add(1)
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.upsertLatched(ihs, results)
+		return results, nil
+	}
+
+	b.resetLatestPointingNodes()
+	currentNode := b.getNextNodeFromHead(ihs[0].Key)
+
+	for i, ih := range ihs {
+		currentNode = b.getCorrectNodeFromItemHandle(ih, currentNode)
+		result, err := currentNode.Upsert(ih)
+		if err != nil && err == ErrNodeIsFull {
+			currentNode = b.splitForKey(currentNode, ih.Key)
+			result, err = currentNode.Upsert(ih)
+			if err != nil {
+				panic(fmt.Sprintf("Should be no error here, means something is broken: %v", err))
+			}
 		}
-		prev.ConnectNode(0, afterNext)
-		next.DisconnectNode(0)
-		next = afterNext
+		results[i] = result
 	}
-	return true, next
+	return results, nil
 }
 
+// splitForKey splits the full `currentNode` into two, connects the new one,
+// and returns whichever of them `key` should go into
+func (b *Bowl[k, v]) splitForKey(currentNode *Node[k, v], key k) *Node[k, v] {
+	newHeight := b.generateLevel()
+	newNode := currentNode.SplitIntoNewNode(newHeight)
+
+	minHeight := newHeight
+	if minHeight > currentNode.GetHeight() {
+		minHeight = currentNode.GetHeight()
+	}
+	b.insertFastPathConnectNewNodeFromCurrent(currentNode, newNode, minHeight)
+	b.setLatestPointingNodes(currentNode)
+	if newHeight > currentNode.GetHeight() {
+		b.connectUntil(newNode, newHeight-1, currentNode.GetHeight())
+	}
+
+	if newNode.checkKeyStrictlyLessThanLowKey(key) {
+		return currentNode
+	}
+	b.setLatestPointingNodes(newNode)
+	return newNode
+}
+
+// getNextNodeAtHeightNotMarkedRemoval unlinks the nodes marked removal starting from `next`,
+// returning the first one which is not, or false if there is none.
+// Nodes still having older versions are kept linked until CollectGarbage drops them.
+//
+// With LOCKING_PER_NODE nothing is ever unlinked, as the ones only latching nodes
+// may still hold it. Marked nodes keep covering their range, and are made alive by later inserts
 func (b *Bowl[k, v]) getNextNodeAtHeightNotMarkedRemoval(
 	h int, prev, next *Node[k, v]) (bool, *Node[k, v]) {
+	if b.lockingMode == LOCKING_PER_NODE {
+		return true, next
+	}
 	atLeast1NotMarkedRemovalAtThisHeight := true
-	for next.MarkedRemoval() {
+	for next.MarkedRemoval() && len(next.history) == 0 {
 		afterNext, _ := next.GetNextNodeAt(h)
 		if afterNext == nil {
 			atLeast1NotMarkedRemovalAtThisHeight = false
 			break
 		}
 		prev.ConnectNode(h, afterNext)
+		if h == 0 {
+			afterNext.ConnectPrevNode(prev)
+		}
 		next.DisconnectNode(h)
 		next = afterNext
 	}
 	return atLeast1NotMarkedRemovalAtThisHeight, next
 }
 
+// getValidNodeToStartScan returns the first node with data, or nil if there is none
 func (b *Bowl[k, v]) getValidNodeToStartScan() *Node[k, v] {
-	node, _ := b.head.GetNextNodeAt(0)
-	if node == nil {
-		return nil
-	}
-	ok, node := b.getNextNodeAtHeightNotMarkedRemoval(0, b.head, node)
-	if !ok {
-		return nil
-	}
-	return node
+	return b.getNextNodeAtHeightWithData(0, b.head)
 }
 
-// ScanAll pass each data to fn
-func (b *Bowl[k, v]) ScanAll(fn func(Item[k, v])) {
-	b.Lock()
-	defer b.Unlock()
+// getNextNodeAtHeightWithData returns the first node after `node` at height h having data,
+// or nil if there is none.
+//
+// Unlike getNextNodeAtHeightNotMarkedRemoval, it never unlinks anything,
+// so it is safe to be called with only the read lock held
+func (b *Bowl[k, v]) getNextNodeAtHeightWithData(h int, node *Node[k, v]) *Node[k, v] {
+	next, _ := node.GetNextNodeAt(h)
+	for next != nil && next.GetCount() == 0 {
+		next, _ = next.GetNextNodeAt(h)
+	}
+	return next
+}
 
-	node := b.getValidNodeToStartScan()
-	if node == nil {
-		return
+// getNodeForRead returns the node that should has the key,
+// or nil if this Bowl has no data.
+//
+// Unlike getNextNodeFromHead and getCorrectNode, it never writes anything,
+// neither creating node, unlinking, nor touching latestPointingNodes
+func (b *Bowl[k, v]) getNodeForRead(key k) *Node[k, v] {
+	node := b.head
+	for h := b.maxHeight - 1; h >= 0; h-- {
+		for {
+			next := b.getNextNodeAtHeightWithData(h, node)
+			if next == nil {
+				break
+			}
+			if next.checkKeyStrictlyLessThanLowKey(key) {
+				break
+			}
+			node = next
+		}
 	}
+	if node == b.head { // key is smaller than everything
+		return b.getValidNodeToStartScan()
+	}
+	return node
+}
 
-	for {
-		node.ScanAll(fn)
-		next, _ := node.GetNextNodeAt(0)
+// getCorrectNodeForRead is the read-only getCorrectNode,
+// moving forward from `currentNode` to the node that should has the key
+func (b *Bowl[k, v]) getCorrectNodeForRead(
+	key k, currentNode *Node[k, v]) *Node[k, v] {
+	h := currentNode.GetHeight() - 1
+	for h >= 0 {
+		if ok, _ := currentNode.CheckKeyStrictlyLessThanMax(key); ok {
+			return currentNode
+		}
+		next := b.getNextNodeAtHeightWithData(h, currentNode)
 		if next == nil {
-			break
+			h--
+			continue
 		}
-		ok, next := b.scanNextNodeNotMarkedRemoval(node, next)
-		if !ok {
-			return
+		if next.checkKeyStrictlyLessThanLowKey(key) {
+			h--
+			continue
 		}
-		node = next // can be nil
+		currentNode = next
+	}
+	return currentNode
+}
+
+// Len returns the number of data in this Bowl
+func (b *Bowl[k, v]) Len() int {
+	b.readLock()
+	defer b.readUnlock()
+
+	count := 0
+	node, _ := b.head.GetNextNodeAt(0)
+	for node != nil {
+		count += node.GetCount()
+		node, _ = node.GetNextNodeAt(0)
 	}
+	return count
+}
+
+// ScanAll pass each data to fn
+func (b *Bowl[k, v]) ScanAll(fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanAllWhile pass each data to fn, and stops as soon as fn returns false
+func (b *Bowl[k, v]) ScanAllWhile(fn func(Item[k, v]) bool) {
+	b.ScanBoundsWhile(Bounds[k]{}, fn)
 }
 
 // ScanGreaterThanEqual pass each data greater than `key` to fn
 func (b *Bowl[k, v]) ScanGreaterThanEqual(
 	key k, fn func(Item[k, v])) {
-	b.Lock()
-	defer b.Unlock()
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
 
-	node := b.getNextNodeFromHead(key)
-	node = b.getCorrectNode(key, node)
-	node.ScanGreaterThanEqual(key, fn)
-	for {
-		next, _ := node.GetNextNodeAt(0)
-		if next == nil {
-			break
-		}
-		ok, next := b.scanNextNodeNotMarkedRemoval(node, next)
-		if !ok {
-			return
-		}
-		node = next
-		node.ScanAll(fn)
-	}
+// ScanGreaterThanEqualWhile pass each data greater than `key` to fn,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ScanGreaterThanEqualWhile(
+	key k, fn func(Item[k, v]) bool) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, fn)
 }
 
-// ScanGreaterThanEqual pass each data until `key` to fn
+// ScanStrictlyLessThan pass each data until `key` to fn
 func (b *Bowl[k, v]) ScanStrictlyLessThan(
 	key k, fn func(Item[k, v])) {
-	b.Lock()
-	defer b.Unlock()
+	b.ScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanStrictlyLessThanWhile pass each data until `key` to fn,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ScanStrictlyLessThanWhile(
+	key k, fn func(Item[k, v]) bool) {
+	b.ScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, fn)
+}
+
+// ScanRange pass each data between fromKey <= data <= toKey
+func (b *Bowl[k, v]) ScanRange(
+	fromKey k, toKey k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanRangeWhile pass each data between fromKey <= data <= toKey,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ScanRangeWhile(
+	fromKey k, toKey k, fn func(Item[k, v]) bool) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, fn)
+}
+
+// ScanBoundsWhile pass each data inside `bounds` to fn,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	b.RLock()
+	defer b.RUnlock()
+	b.scanBounds(context.Background(), bounds, fn)
+}
+
+// scanBounds pass each data inside `bounds` to fn, and stops as soon as fn returns false.
+// ctx is checked between nodes, returning its error when it is done
+//
+// Should only be called when RLock is held
+func (b *Bowl[k, v]) scanBounds(ctx context.Context, bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	if b.lockingMode == LOCKING_PER_NODE {
+		return b.scanBoundsLatched(ctx, bounds, fn)
+	}
 
-	node := b.getValidNodeToStartScan()
+	var node *Node[k, v]
+	if bounds.Lower.Type == UNBOUNDED {
+		node = b.getValidNodeToStartScan()
+	} else {
+		node = b.getNodeForRead(bounds.Lower.Key)
+	}
 	if node == nil {
-		return
+		return nil
 	}
 
 	for {
-		ok, _ := node.CheckKeyStrictlyLessThanMax(key)
-		if ok {
-			node.ScanStrictlyLessThan(key, fn)
-			break
-		} else { // bigger than max
-			node.ScanAll(fn)
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		next, _ := node.GetNextNodeAt(0)
-		if next == nil {
-			break
+		if !node.ScanBoundsWhile(bounds, fn) {
+			return nil
 		}
-		ok, next = b.scanNextNodeNotMarkedRemoval(node, next)
-		if !ok {
-			return
+		// everything after this node is bigger than its max
+		maxKey, err := node.GetMaxKey(bounds.Upper.Key)
+		if err == nil && !bounds.satisfiesUpper(b.cmp, maxKey) {
+			return nil
 		}
-		ok, _ = next.CheckKeyStrictlyLessThanMin(key)
-		if ok {
-			break
+		node = b.getNextNodeAtHeightWithData(0, node)
+		if node == nil {
+			return nil
 		}
-		node = next
 	}
 }
 
-// ScanRange pass each data between fromKey <= data <= toKey
-func (b *Bowl[k, v]) ScanRange(
+// getLastNode returns the last node not marked removal, or nil if there is none
+//
+// It only follows the pointers, without unlinking anything
+func (b *Bowl[k, v]) getLastNode() *Node[k, v] {
+	node := b.head
+	for h := b.maxHeight - 1; h >= 0; h-- {
+		for {
+			next, _ := node.GetNextNodeAt(h)
+			for next != nil && next.MarkedRemoval() {
+				next, _ = next.GetNextNodeAt(h)
+			}
+			if next == nil {
+				break
+			}
+			node = next
+		}
+	}
+	if node == b.head {
+		return nil
+	}
+	return node
+}
+
+// getPrevNodeNotMarkedRemoval follows the back-links at height 0,
+// returning nil once it reaches head
+func (b *Bowl[k, v]) getPrevNodeNotMarkedRemoval(node *Node[k, v]) *Node[k, v] {
+	prev := node.GetPrevNode()
+	for prev != nil && prev != b.head && prev.MarkedRemoval() {
+		prev = prev.GetPrevNode()
+	}
+	if prev == b.head {
+		return nil
+	}
+	return prev
+}
+
+// ReverseScanAll pass each data to fn, in descending order
+func (b *Bowl[k, v]) ReverseScanAll(fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ReverseScanAllWhile pass each data to fn, in descending order,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ReverseScanAllWhile(fn func(Item[k, v]) bool) {
+	b.ReverseScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ReverseScanGreaterThanEqual pass each data greater than `key` to fn, in descending order
+func (b *Bowl[k, v]) ReverseScanGreaterThanEqual(
+	key k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanGreaterThanEqualWhile pass each data greater than `key` to fn, in descending order,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ReverseScanGreaterThanEqualWhile(
+	key k, fn func(Item[k, v]) bool) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, fn)
+}
+
+// ReverseScanStrictlyLessThan pass each data until `key` to fn, in descending order
+func (b *Bowl[k, v]) ReverseScanStrictlyLessThan(
+	key k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanStrictlyLessThanWhile pass each data until `key` to fn, in descending order,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ReverseScanStrictlyLessThanWhile(
+	key k, fn func(Item[k, v]) bool) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, fn)
+}
+
+// ReverseScanRange pass each data between fromKey <= data <= toKey, in descending order
+func (b *Bowl[k, v]) ReverseScanRange(
 	fromKey k, toKey k, fn func(Item[k, v])) {
-	b.Lock()
-	defer b.Unlock()
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
 
-	node := b.getNextNodeFromHead(fromKey)
-	node = b.getCorrectNode(fromKey, node)
+// ReverseScanRangeWhile pass each data between fromKey <= data <= toKey, in descending order,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ReverseScanRangeWhile(
+	fromKey k, toKey k, fn func(Item[k, v]) bool) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, fn)
+}
 
-	// when all the values are all contained in the node
-	ok, _ := node.CheckKeyStrictlyLessThanMax(toKey)
-	if ok {
-		node.ScanRange(fromKey, toKey, fn)
-		return
+// ReverseScanBoundsWhile pass each data inside `bounds` to fn, in descending order,
+// and stops as soon as fn returns false
+func (b *Bowl[k, v]) ReverseScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	b.RLock()
+	defer b.RUnlock()
+	b.reverseScanBounds(context.Background(), bounds, fn)
+}
+
+// reverseScanBounds pass each data inside `bounds` to fn, in descending order,
+// and stops as soon as fn returns false. ctx is checked between nodes, returning its error when it is done
+//
+// Should only be called when RLock is held
+func (b *Bowl[k, v]) reverseScanBounds(ctx context.Context, bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	if b.lockingMode == LOCKING_PER_NODE {
+		return b.reverseScanBoundsLatched(ctx, bounds, fn)
 	}
 
-	// possibly more than current node only
-	node.ScanGreaterThanEqual(fromKey, fn)
-	for {
-		next, _ := node.GetNextNodeAt(0)
-		if next == nil {
-			break
-		}
-		ok, next := b.scanNextNodeNotMarkedRemoval(node, next)
-		if !ok {
-			return
-		}
-		node = next
+	var node *Node[k, v]
+	if bounds.Upper.Type == UNBOUNDED {
+		node = b.getLastNode()
+	} else {
+		node = b.getNodeForRead(bounds.Upper.Key)
+	}
 
-		ok, _ = node.CheckKeyStrictlyLessThanMax(toKey)
-		if ok {
-			node.ScanStrictlyLessThan(toKey, fn)
-			break
-		} else { // bigger than max
-			node.ScanAll(fn)
+	for node != nil {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-
-		ok, _ = next.CheckKeyStrictlyLessThanMin(toKey)
-		if ok {
-			break
+		if !node.ReverseScanBoundsWhile(bounds, fn) {
+			return nil
+		}
+		// everything before this node is smaller than its min
+		minKey, err := node.GetMinKey(bounds.Lower.Key)
+		if err == nil && !bounds.satisfiesLower(b.cmp, minKey) {
+			return nil
 		}
+		node = b.getPrevNodeNotMarkedRemoval(node)
 	}
+	return nil
 }
 
 // getNextNodeFromHead returns only the next node, and should be fast
@@ -362,7 +774,7 @@ func (b *Bowl[k, v]) ScanRange(
 //
 // The node returned will never be nil, and is already locked
 func (b *Bowl[k, v]) getNextNodeFromHead(key k) *Node[k, v] {
-	for h := MAX_HEIGHT - 1; h > 0; {
+	for h := b.maxHeight - 1; h > 0; {
 		next, _ := b.head.GetNextNodeAt(h)
 		if next == nil {
 			h--
@@ -375,8 +787,7 @@ func (b *Bowl[k, v]) getNextNodeFromHead(key k) *Node[k, v] {
 			continue
 		}
 
-		ok, err := next.CheckKeyStrictlyLessThanMin(key)
-		if err != nil && !ok { // meaning bigger than next min
+		if !next.checkKeyStrictlyLessThanLowKey(key) { // meaning next covers key, or after
 			b.setLatestPointingNodes(next)
 			return next
 		}
@@ -388,11 +799,12 @@ func (b *Bowl[k, v]) getNextNodeFromHead(key k) *Node[k, v] {
 	n, _ := b.head.GetNextNodeAt(0)
 	if n == nil {
 		// meaning this BOWL is empty, create new
-		nextHeight := generateLevel(MAX_HEIGHT)
-		newNode := NewEmptyNode[k, v](nextHeight, b.cmp)
+		nextHeight := b.generateLevel()
+		newNode := b.createNode(nextHeight)
 		for i := 0; i < nextHeight; i++ {
 			b.head.ConnectNode(i, newNode)
 		}
+		newNode.ConnectPrevNode(b.head)
 		b.setLatestPointingNodes(newNode)
 		return newNode
 	}
@@ -434,8 +846,7 @@ func (b *Bowl[k, v]) getCorrectNode(
 			}
 
 			atLeastCheck1NextNode = true
-			ok, _ = next.CheckKeyStrictlyLessThanMin(key)
-			if ok {
+			if next.checkKeyStrictlyLessThanLowKey(key) {
 				h--
 				continue
 			}
@@ -1,8 +1,10 @@
 package bowl
 
 import (
+	"errors"
 	"math"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 )
@@ -199,6 +201,14 @@ func TestBowl(t *testing.T) {
 }
 
 func BenchmarkBowlWrite(b *testing.B) {
+	benchmarkBowlWrite(b)
+}
+
+func BenchmarkBowlWriteStrict(b *testing.B) {
+	benchmarkBowlWrite(b, WithStrictValidation())
+}
+
+func benchmarkBowlWrite(b *testing.B, opts ...Option) {
 	// we only test insert
 	// as it is already representative about update and delete
 	//
@@ -222,7 +232,7 @@ func BenchmarkBowlWrite(b *testing.B) {
 
 	b.Log("set counter to 0")
 	counter := 0
-	bowl := NewBOWL[int, int](cmpTest)
+	bowl := NewBOWL[int, int](cmpTest, opts...)
 	for i := 0; i < b.N; i++ {
 		errs := bowl.Insert(allData[i])
 		for _, err := range errs {
@@ -248,8 +258,16 @@ func BenchmarkBowlWrite(b *testing.B) {
 }
 
 func BenchmarkBowlRead(b *testing.B) {
+	benchmarkBowlRead(b)
+}
+
+func BenchmarkBowlReadStrict(b *testing.B) {
+	benchmarkBowlRead(b, WithStrictValidation())
+}
+
+func benchmarkBowlRead(b *testing.B, opts ...Option) {
 	b.StopTimer()
-	bowl := NewBOWL[int, int](cmpTest)
+	bowl := NewBOWL[int, int](cmpTest, opts...)
 	chInsert := make(chan []Item[int, int], 4096)
 	chRead := make(chan []int, 4096)
 
@@ -293,3 +311,562 @@ func BenchmarkBowlRead(b *testing.B) {
 		bowl.Get(data, math.MinInt)
 	}
 }
+
+// BenchmarkBowlReadParallel runs Get from all goroutines at once,
+// run with -cpu 1,2,4,8 to see read throughput scaling with GOMAXPROCS
+func BenchmarkBowlReadParallel(b *testing.B) {
+	b.StopTimer()
+	bowl := NewBOWL[int, int](cmpTest)
+	rnd := rand.New(rand.NewSource(rand.Int63()))
+	for i := 0; i < 2048; i++ {
+		data := make([]Item[int, int], 0, 1024)
+		prev := 0
+		for j := 0; j < 1024; j++ {
+			val := prev + rnd.Intn(65536) + 1
+			data = append(data, Item[int, int]{Key: val, Value: val})
+			prev = val
+		}
+		bowl.Insert(data)
+	}
+	b.StartTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		data := make([]int, 1024)
+		for pb.Next() {
+			prev := 0
+			for j := range data {
+				prev += rnd.Intn(65536) + 1
+				data[j] = prev
+			}
+			bowl.Get(data, math.MinInt)
+		}
+	})
+}
+
+func TestBowlScanWhile(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+
+	// enough data to span multiple nodes
+	ihs := make([]Item[int, int], 0, 2000)
+	for i := 0; i < 2000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 2, Value: i * 2})
+	}
+	errs := b.Insert(ihs)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Shouldn't return error when inserting, but instead at iter %d we got %v", i, err)
+		}
+	}
+
+	collect := func(limit int, got *[]int) func(Item[int, int]) bool {
+		return func(ih Item[int, int]) bool {
+			if len(*got) >= limit {
+				t.Fatalf("fn should not be called anymore after returning false, but it is called with %d", ih.Key)
+			}
+			*got = append(*got, ih.Key)
+			return len(*got) < limit
+		}
+	}
+
+	got := make([]int, 0, 300)
+	b.ScanAllWhile(collect(300, &got))
+	if len(got) != 300 || got[0] != 0 || got[299] != 598 {
+		t.Fatalf("Should get the first 300 keys, from 0 to 598, but instead we got %d keys: %v", len(got), got)
+	}
+
+	got = got[:0]
+	b.ScanGreaterThanEqualWhile(1001, collect(5, &got))
+	if len(got) != 5 || got[0] != 1002 || got[4] != 1010 {
+		t.Fatalf("Should get 5 keys starting from 1002, but instead we got %v", got)
+	}
+
+	got = got[:0]
+	b.ScanStrictlyLessThanWhile(3000, collect(400, &got))
+	if len(got) != 400 || got[399] != 798 {
+		t.Fatalf("Should get the first 400 keys, until 798, but instead we got %d keys", len(got))
+	}
+
+	got = got[:0]
+	b.ScanRangeWhile(100, 3000, collect(700, &got))
+	if len(got) != 700 || got[0] != 100 || got[699] != 1498 {
+		t.Fatalf("Should get 700 keys from 100 to 1498, but instead we got %d keys", len(got))
+	}
+
+	// lock should have been released after stopping early
+	res := b.Get([]int{0, 3998}, math.MinInt)
+	if res[0] != 0 || res[1] != 3998 {
+		t.Fatalf("Should get 0 and 3998, but instead we got %v", res)
+	}
+
+	// all scans should still reach the end when never stopped
+	count := 0
+	b.ScanGreaterThanEqualWhile(0, func(ih Item[int, int]) bool {
+		count++
+		return true
+	})
+	if count != 2000 {
+		t.Fatalf("Should scan all 2000 data, but instead we got %d", count)
+	}
+}
+
+// checkBowlLinks validates that back-links mirror height 0,
+// and that every height only points forward in height 0 order
+func checkBowlLinks[k comparable, v any](t *testing.T, b *Bowl[k, v]) {
+	t.Helper()
+	position := make(map[*Node[k, v]]int)
+	prev := b.head
+	node, _ := b.head.GetNextNodeAt(0)
+	for i := 0; node != nil; i++ {
+		if node.GetPrevNode() != prev {
+			t.Fatalf("Back-link at position %d should point to the previous node at height 0, but it is not", i)
+		}
+		position[node] = i
+		prev = node
+		node, _ = node.GetNextNodeAt(0)
+	}
+	for h := 1; h < b.maxHeight; h++ {
+		last := -1
+		node, _ := b.head.GetNextNodeAt(h)
+		for node != nil {
+			pos, ok := position[node]
+			if !ok && !node.MarkedRemoval() {
+				t.Fatalf("Node at height %d should also be linked at height 0, but it is not", h)
+			}
+			if ok {
+				if pos <= last {
+					t.Fatalf("Height %d should only point forward, but went from position %d to %d", h, last, pos)
+				}
+				last = pos
+			}
+			node, _ = node.GetNextNodeAt(h)
+		}
+	}
+}
+
+func TestBowlReverseScan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	b := NewBOWL[int, int](cmpTest)
+	model := make(map[int]bool)
+
+	sortedKeys := func(m map[int]bool) []int {
+		keys := make([]int, 0, len(m))
+		for i := 0; i < 20000; i++ {
+			if m[i] {
+				keys = append(keys, i)
+			}
+		}
+		return keys
+	}
+	check := func(stage string) {
+		checkBowlLinks(t, b)
+		keys := sortedKeys(model)
+
+		got := make([]int, 0, len(keys))
+		b.ReverseScanAll(func(ih Item[int, int]) {
+			got = append(got, ih.Key)
+		})
+		if len(got) != len(keys) {
+			t.Fatalf("%s: ReverseScanAll should return %d data, but instead we got %d", stage, len(keys), len(got))
+		}
+		for i := range got {
+			if got[i] != keys[len(keys)-1-i] {
+				t.Fatalf("%s: ReverseScanAll at iter %d should be %d, but instead we got %d", stage, i, keys[len(keys)-1-i], got[i])
+			}
+		}
+
+		for iter := 0; iter < 20; iter++ {
+			from := rnd.Intn(20000)
+			to := from + rnd.Intn(3000)
+
+			expected := make([]int, 0)
+			for i := len(keys) - 1; i >= 0; i-- {
+				if keys[i] >= from && keys[i] <= to {
+					expected = append(expected, keys[i])
+				}
+			}
+			got = got[:0]
+			b.ReverseScanRange(from, to, func(ih Item[int, int]) {
+				got = append(got, ih.Key)
+			})
+			if len(got) != len(expected) {
+				t.Fatalf("%s: ReverseScanRange(%d, %d) should return %d data, but instead we got %d", stage, from, to, len(expected), len(got))
+			}
+			for i := range got {
+				if got[i] != expected[i] {
+					t.Fatalf("%s: ReverseScanRange(%d, %d) at iter %d should be %d, but instead we got %d", stage, from, to, i, expected[i], got[i])
+				}
+			}
+
+			ltCount, gteCount := 0, 0
+			for _, key := range keys {
+				if key < from {
+					ltCount++
+				} else {
+					gteCount++
+				}
+			}
+			prevKey := math.MaxInt
+			got = got[:0]
+			b.ReverseScanStrictlyLessThan(from, func(ih Item[int, int]) {
+				if ih.Key >= prevKey || ih.Key >= from {
+					t.Fatalf("%s: ReverseScanStrictlyLessThan(%d) should be descending and less than key, but we got %d after %d", stage, from, ih.Key, prevKey)
+				}
+				prevKey = ih.Key
+				got = append(got, ih.Key)
+			})
+			if len(got) != ltCount {
+				t.Fatalf("%s: ReverseScanStrictlyLessThan(%d) should return %d data, but instead we got %d", stage, from, ltCount, len(got))
+			}
+			prevKey = math.MaxInt
+			got = got[:0]
+			b.ReverseScanGreaterThanEqual(from, func(ih Item[int, int]) {
+				if ih.Key >= prevKey || ih.Key < from {
+					t.Fatalf("%s: ReverseScanGreaterThanEqual(%d) should be descending and at least key, but we got %d after %d", stage, from, ih.Key, prevKey)
+				}
+				prevKey = ih.Key
+				got = append(got, ih.Key)
+			})
+			if len(got) != gteCount {
+				t.Fatalf("%s: ReverseScanGreaterThanEqual(%d) should return %d data, but instead we got %d", stage, from, gteCount, len(got))
+			}
+		}
+	}
+
+	check("empty")
+	for round := 0; round < 10; round++ {
+		batch := make(map[int]bool)
+		for i := 0; i < 1500; i++ {
+			batch[rnd.Intn(20000)] = true
+		}
+		ihs := make([]Item[int, int], 0, len(batch))
+		for _, key := range sortedKeys(batch) {
+			ihs = append(ihs, Item[int, int]{Key: key, Value: key})
+			model[key] = true
+		}
+		b.Insert(ihs)
+		check("after insert")
+
+		// delete a whole range, so some nodes got marked removal
+		from := rnd.Intn(20000)
+		toDelete := make([]int, 0)
+		for _, key := range sortedKeys(model) {
+			if key >= from && key < from+2500 {
+				toDelete = append(toDelete, key)
+				delete(model, key)
+			}
+		}
+		if len(toDelete) > 0 {
+			b.Delete(toDelete)
+		}
+		check("after delete")
+	}
+
+	// remove everything, then fill again through the node left marked removal
+	b.Delete(sortedKeys(model))
+	model = make(map[int]bool)
+	check("after delete all")
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 3, Value: i * 3})
+		model[i*3] = true
+	}
+	b.Insert(ihs[:500])
+	b.Insert(ihs[500:])
+	check("after refill")
+
+	stopped := 0
+	b.ReverseScanAllWhile(func(ih Item[int, int]) bool {
+		stopped++
+		return stopped < 10
+	})
+	if stopped != 10 {
+		t.Fatalf("ReverseScanAllWhile should stop after 10 data, but instead we got %d", stopped)
+	}
+}
+
+func TestBowlScanBoundsAtNodeEdges(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 3000)
+	for i := 0; i < 3000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 2, Value: i * 2})
+	}
+	b.Insert(ihs)
+
+	// edges of every node, plus keys right around them
+	edges := make([]int, 0)
+	node, _ := b.head.GetNextNodeAt(0)
+	for node != nil {
+		minKey, _ := node.GetMinKey(0)
+		maxKey, _ := node.GetMaxKey(0)
+		edges = append(edges, minKey-1, minKey, minKey+1, maxKey-1, maxKey, maxKey+1)
+		node, _ = node.GetNextNodeAt(0)
+	}
+	if len(edges) < 6*4 {
+		t.Fatalf("Data should span at least 4 nodes, but instead we only have %d", len(edges)/6)
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	boundTypes := []BoundType{UNBOUNDED, INCLUSIVE, EXCLUSIVE}
+	for _, lowerType := range boundTypes {
+		for _, upperType := range boundTypes {
+			for iter := 0; iter < 50; iter++ {
+				bounds := Bounds[int]{
+					Lower: Bound[int]{Type: lowerType, Key: edges[rnd.Intn(len(edges))]},
+					Upper: Bound[int]{Type: upperType, Key: edges[rnd.Intn(len(edges))]},
+				}
+				expected := make([]int, 0)
+				for _, ih := range ihs {
+					if bounds.satisfiesLower(cmpTest, ih.Key) && bounds.satisfiesUpper(cmpTest, ih.Key) {
+						expected = append(expected, ih.Key)
+					}
+				}
+
+				got := make([]int, 0, len(expected))
+				b.ScanBoundsWhile(bounds, func(ih Item[int, int]) bool {
+					got = append(got, ih.Key)
+					return true
+				})
+				if len(got) != len(expected) {
+					t.Fatalf("%v: ScanBoundsWhile should return %d data, but instead we got %d", bounds, len(expected), len(got))
+				}
+				for i := range got {
+					if got[i] != expected[i] {
+						t.Fatalf("%v: ScanBoundsWhile at iter %d should be %d, but instead we got %d", bounds, i, expected[i], got[i])
+					}
+				}
+
+				got = got[:0]
+				b.ReverseScanBoundsWhile(bounds, func(ih Item[int, int]) bool {
+					got = append(got, ih.Key)
+					return true
+				})
+				if len(got) != len(expected) {
+					t.Fatalf("%v: ReverseScanBoundsWhile should return %d data, but instead we got %d", bounds, len(expected), len(got))
+				}
+				for i := range got {
+					if got[i] != expected[len(expected)-1-i] {
+						t.Fatalf("%v: ReverseScanBoundsWhile at iter %d should be %d, but instead we got %d", bounds, i, expected[len(expected)-1-i], got[i])
+					}
+				}
+			}
+		}
+	}
+
+	// ScanRange includes both ends
+	sum := 0
+	b.ScanRange(10, 20, func(ih Item[int, int]) {
+		sum += ih.Key
+	})
+	if sum != 90 {
+		t.Fatalf("ScanRange(10, 20) should include both ends and total 90, but instead we got %d", sum)
+	}
+}
+
+func TestBowlUpsert(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 3, Value: i * 3})
+	}
+	b.Insert(ihs)
+
+	// every multiple of 3 already exists, everything else causes splits
+	upserts := make([]Item[int, int], 0, 3000)
+	for i := 0; i < 3000; i++ {
+		upserts = append(upserts, Item[int, int]{Key: i, Value: -i})
+	}
+	results := b.Upsert(upserts)
+	for i, result := range results {
+		if i%3 == 0 && result != UPSERT_REPLACED {
+			t.Fatalf("Key %d already exists, so should be UPSERT_REPLACED, but instead we got %v", i, result)
+		}
+		if i%3 != 0 && result != UPSERT_CREATED {
+			t.Fatalf("Key %d does not exist yet, so should be UPSERT_CREATED, but instead we got %v", i, result)
+		}
+	}
+
+	count := 0
+	b.ScanAll(func(ih Item[int, int]) {
+		if ih.Key != count || ih.Value != -count {
+			t.Fatalf("At iter %d, it should be %d/%d, but instead we got %d/%d", count, count, -count, ih.Key, ih.Value)
+		}
+		count++
+	})
+	if count != 3000 {
+		t.Fatalf("There should be 3000 data after upsert, but instead we got %d", count)
+	}
+	checkBowlLinks(t, b)
+}
+
+func TestBowlStrictValidation(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest, WithStrictValidation())
+
+	// empty batches are no-ops
+	if len(b.Insert(nil)) != 0 || len(b.Update(nil)) != 0 || len(b.Delete(nil)) != 0 || len(b.Get(nil, 0)) != 0 {
+		t.Fatal("Empty batches should return empty results")
+	}
+
+	errs := b.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 3, Value: 3}, {Key: 2, Value: 2}})
+	var unsortedErr ErrUnsortedBatch
+	for i, err := range errs {
+		if !errors.As(err, &unsortedErr) || unsortedErr.Index != 2 {
+			t.Fatalf("Every error should be ErrUnsortedBatch at index 2, but instead at iter %d we got %v", i, err)
+		}
+	}
+	if res := b.Get([]int{1, 2, 3}, math.MinInt); res[0] != math.MinInt {
+		t.Fatalf("Nothing should be inserted from an invalid batch, but instead we got %v", res)
+	}
+
+	errs = b.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}, {Key: 3, Value: 3}})
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Sorted batch should be inserted, but instead at iter %d we got %v", i, err)
+		}
+	}
+
+	var dupErr ErrDuplicateKeyInBatch
+	errs = b.Update([]Item[int, int]{{Key: 1, Value: 10}, {Key: 1, Value: 11}})
+	if !errors.As(errs[0], &dupErr) || dupErr.Index != 1 {
+		t.Fatalf("Update should return ErrDuplicateKeyInBatch at index 1, but instead we got %v", errs[0])
+	}
+	errs = b.Delete([]int{3, 2})
+	if !errors.As(errs[1], &unsortedErr) || unsortedErr.Index != 1 {
+		t.Fatalf("Delete should return ErrUnsortedBatch at index 1, but instead we got %v", errs[1])
+	}
+
+	_, err := b.TryGet([]int{2, 2}, math.MinInt)
+	if !errors.As(err, &dupErr) || dupErr.Index != 1 {
+		t.Fatalf("TryGet should return ErrDuplicateKeyInBatch at index 1, but instead we got %v", err)
+	}
+	res, err := b.TryGet([]int{1, 2, 3}, math.MinInt)
+	if err != nil || res[0] != 1 || res[1] != 2 || res[2] != 3 {
+		t.Fatalf("TryGet should return 1, 2, 3 without error, but instead we got %v and %v", res, err)
+	}
+
+	if res := b.Get([]int{1, 3}, math.MinInt); res[0] != 1 || res[1] != 3 {
+		t.Fatalf("Get in strict mode should return 1, 3, but instead we got %v", res)
+	}
+	// Get has no error to return, so an invalid batch finds nothing
+	if res := b.Get([]int{3, 1}, math.MinInt); len(res) != 2 || res[0] != math.MinInt || res[1] != math.MinInt {
+		t.Fatalf("Get with an invalid batch should return only the default value, but instead we got %v", res)
+	}
+}
+
+func TestBowlReadsDoNotWrite(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(4))
+	keys := make([]int, 0, 100)
+	ihs := make([]Item[int, int], 0, 100)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, i)
+		ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+	}
+	b.Insert(ihs)
+
+	// empty the second node, so it is marked but still linked
+	first, _ := b.head.GetNextNodeAt(0)
+	marked, _ := first.GetNextNodeAt(0)
+	deleted := make(map[int]bool)
+	marked.ScanAll(func(ih Item[int, int]) {
+		deleted[ih.Key] = true
+	})
+	toDelete := make([]int, 0, len(deleted))
+	for _, key := range keys {
+		if deleted[key] {
+			toDelete = append(toDelete, key)
+		}
+	}
+	b.Delete(toDelete)
+	if !marked.MarkedRemoval() {
+		t.Fatal("Node with all its data deleted should be marked removal, but it is not")
+	}
+	latest := append([]*Node[int, int](nil), b.latestPointingNodes...)
+
+	for i, value := range b.Get(keys, math.MinInt) {
+		if deleted[i] && value != math.MinInt {
+			t.Fatalf("Key %d should be deleted, but instead we got %d", i, value)
+		}
+		if !deleted[i] && value != i {
+			t.Fatalf("Key %d should be found, but instead we got %d", i, value)
+		}
+	}
+	count := 0
+	b.ScanAll(func(ih Item[int, int]) { count++ })
+	b.ReverseScanRange(10, 90, func(ih Item[int, int]) {})
+	b.ScanGreaterThanEqual(toDelete[0], func(ih Item[int, int]) {})
+	c := b.NewCursor()
+	for ok := c.Seek(toDelete[0]); ok; ok = c.Next() {
+	}
+	for ok := c.Last(); ok; ok = c.Prev() {
+	}
+	if count != 100-len(toDelete) {
+		t.Fatalf("ScanAll should skip the deleted data, and return %d data, but instead we got %d", 100-len(toDelete), count)
+	}
+
+	if next, _ := first.GetNextNodeAt(0); next != marked {
+		t.Fatal("Reads should not unlink the marked node, but it got unlinked")
+	}
+	for i := range latest {
+		if b.latestPointingNodes[i] != latest[i] {
+			t.Fatalf("Reads should not touch latestPointingNodes, but at height %d it changed", i)
+		}
+	}
+
+	// reading an empty Bowl does not create any node
+	empty := NewBOWL[int, int](cmpTest)
+	empty.Get([]int{1, 2, 3}, math.MinInt)
+	empty.ScanGreaterThanEqual(1, func(ih Item[int, int]) {})
+	empty.NewCursor().Seek(1)
+	if next, _ := empty.head.GetNextNodeAt(0); next != nil {
+		t.Fatal("Reading an empty Bowl should not create any node, but it did")
+	}
+}
+
+func TestBowlConcurrentReaders(t *testing.T) {
+	// run with -race, readers only share the read lock with each other
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(16))
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				prev := -1
+				b.ScanAll(func(ih Item[int, int]) {
+					if ih.Key <= prev {
+						t.Errorf("Scan should be ascending, but %d came after %d", ih.Key, prev)
+					}
+					prev = ih.Key
+				})
+				for _, value := range b.Get([]int{10, 500, 999}, math.MinInt) {
+					if value != math.MinInt && value%2 != 0 {
+						t.Errorf("Only even values are inserted, but instead we got %d", value)
+					}
+				}
+			}
+		}()
+	}
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 200; i++ {
+		ihs := make([]Item[int, int], 0, 64)
+		keys := make([]int, 0, 64)
+		for j := rnd.Intn(20); j < 1000; j += rnd.Intn(30) + 1 {
+			ihs = append(ihs, Item[int, int]{Key: j, Value: j * 2})
+			keys = append(keys, j)
+		}
+		if i%2 == 0 {
+			b.Upsert(ihs)
+		} else {
+			b.Delete(keys)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package bowl
+
+// CASItem asks to set Key to New, only if its current value equals Expected
+type CASItem[k comparable, v any] struct {
+	Key      k
+	Expected v
+	New      v
+}
+
+// CASResult tells what CompareAndSwap did to an item
+type CASResult int
+
+const (
+	CAS_SWAPPED   CASResult = 0
+	CAS_MISMATCH  CASResult = 1
+	CAS_NOT_FOUND CASResult = 2
+)
+
+// CompareAndSwap sets each cas.Key to cas.New, only when its current value equals cas.Expected,
+// as decided by `equal`. It never inserts nor deletes, so just like Update,
+// it is a single ordered pass, under a single lock.
+// All swaps are decided first, then logged to the write-ahead log, if any, and only then applied.
+// So if the log fails, the error is returned and nothing is swapped, see OpenBOWL
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *Bowl[k, v]) CompareAndSwap(cass []CASItem[k, v], equal func(a, b v) bool) ([]CASResult, error) {
+	results := make([]CASResult, len(cass))
+	if len(cass) == 0 {
+		return results, nil
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	swaps := make([]batchOp[k, v], 0, len(cass))
+	for i, cas := range cass {
+		current, exists := b.getPlannedItem(swaps, cas.Key)
+		switch {
+		case !exists:
+			results[i] = CAS_NOT_FOUND
+		case !equal(current.Value, cas.Expected):
+			results[i] = CAS_MISMATCH
+		default:
+			results[i] = CAS_SWAPPED
+			swaps = append(swaps, batchOp[k, v]{opType: OP_UPDATE, item: Item[k, v]{Key: cas.Key, Value: cas.New}, index: len(swaps)})
+		}
+	}
+	if err := b.applyPlanned(swaps); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,103 @@
+package bowl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBowlCompareAndSwap(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 2, Value: i})
+	}
+	b.Insert(ihs)
+
+	equal := func(a, b int) bool { return a == b }
+	cass := make([]CASItem[int, int], 0, 2000)
+	for i := 0; i < 2000; i++ {
+		expected := i / 2
+		if i%4 == 2 {
+			expected = -1
+		}
+		cass = append(cass, CASItem[int, int]{Key: i, Expected: expected, New: i * 100})
+	}
+	results, err := b.CompareAndSwap(cass, equal)
+	if err != nil {
+		t.Fatalf("CompareAndSwap without a write-ahead log should never fail, but instead we got %v", err)
+	}
+
+	keys := make([]int, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		keys = append(keys, i)
+	}
+	res := b.Get(keys, math.MinInt)
+	for i, result := range results {
+		switch {
+		case i%2 == 1:
+			if result != CAS_NOT_FOUND || res[i] != math.MinInt {
+				t.Fatalf("Key %d does not exist, so should be CAS_NOT_FOUND and stay missing, but instead we got %v and %d", i, result, res[i])
+			}
+		case i%4 == 2:
+			if result != CAS_MISMATCH || res[i] != i/2 {
+				t.Fatalf("Key %d has a different value, so should be CAS_MISMATCH and stay %d, but instead we got %v and %d", i, i/2, result, res[i])
+			}
+		default:
+			if result != CAS_SWAPPED || res[i] != i*100 {
+				t.Fatalf("Key %d matches, so should be CAS_SWAPPED into %d, but instead we got %v and %d", i, i*100, result, res[i])
+			}
+		}
+	}
+
+	// the same batch again should all mismatch, as the swapped ones already changed
+	results, _ = b.CompareAndSwap(cass[4:8], equal)
+	if results[0] != CAS_MISMATCH || results[2] != CAS_MISMATCH {
+		t.Fatalf("Already swapped keys should now be CAS_MISMATCH, but instead we got %v", results)
+	}
+}
+
+func TestBowlCompareAndSwapEdgeCases(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(4))
+	equal := func(a, b int) bool { return a == b }
+	if results, err := b.CompareAndSwap(nil, equal); err != nil || len(results) != 0 {
+		t.Fatalf("Empty batch should be a no-op, but instead we got %v %v", results, err)
+	}
+	if results, _ := b.CompareAndSwap([]CASItem[int, int]{{Key: 1, Expected: 0, New: 1}}, equal); results[0] != CAS_NOT_FOUND {
+		t.Fatalf("CompareAndSwap on an empty Bowl should be CAS_NOT_FOUND, but instead we got %v", results)
+	}
+	b.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 3, Value: 3}, {Key: 5, Value: 5}})
+
+	// missing, mismatching, and matching keys mixed in one batch,
+	// with a repeated key seeing the swap before it
+	results, err := b.CompareAndSwap([]CASItem[int, int]{
+		{Key: 0, Expected: 0, New: 100},
+		{Key: 1, Expected: 2, New: 100},
+		{Key: 3, Expected: 3, New: 30},
+		{Key: 3, Expected: 3, New: 300},
+		{Key: 3, Expected: 30, New: 31},
+		{Key: 4, Expected: 0, New: 100},
+		{Key: 5, Expected: 5, New: 50},
+	}, equal)
+	expected := []CASResult{CAS_NOT_FOUND, CAS_MISMATCH, CAS_SWAPPED, CAS_MISMATCH, CAS_SWAPPED, CAS_NOT_FOUND, CAS_SWAPPED}
+	if err != nil || len(results) != len(expected) {
+		t.Fatalf("CompareAndSwap should give %d results, but instead we got %v %v", len(expected), results, err)
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatalf("Result %d should be %v, but instead we got %v", i, expected[i], results[i])
+		}
+	}
+	// nothing inserted for the missing keys, nothing changed for the mismatching one
+	checkSameContent(t, "after mixed batch",
+		[]Item[int, int]{{Key: 1, Value: 1}, {Key: 3, Value: 31}, {Key: 5, Value: 50}}, snapshotContent(b))
+
+	// equality is the caller's, not ==
+	sameSign := func(a, b int) bool { return (a < 0) == (b < 0) }
+	results, _ = b.CompareAndSwap([]CASItem[int, int]{{Key: 1, Expected: 1000, New: -1}, {Key: 5, Expected: -1, New: 0}}, sameSign)
+	if results[0] != CAS_SWAPPED || results[1] != CAS_MISMATCH {
+		t.Fatalf("Custom equality should swap 1 and not 5, but instead we got %v", results)
+	}
+	if res := b.Get([]int{1, 5}, math.MinInt); res[0] != -1 || res[1] != 50 {
+		t.Fatalf("Keys should be -1 and 50, but instead we got %v", res)
+	}
+}
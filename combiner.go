@@ -0,0 +1,180 @@
+package bowl
+
+import (
+	"slices"
+	"sync"
+)
+
+// MAX_COMBINE_ROUNDS is how many rounds a combiner applies,
+// before handing the role to a waiting writer
+const MAX_COMBINE_ROUNDS int = 8
+
+// Combiner is a write front end of a Bowl for many concurrent writers with small batches.
+//
+// Instead of each writer taking the Bowl's lock for its own batch, writers queue their batches,
+// and one of them, the combiner, merges every pending batch into a single sorted pass
+// under a single lock, while the others wait for their results.
+// Each writer still gets back the errors of its own batch only.
+//
+// Batches queued at the same time are applied in the order they were queued,
+// so when they share a key, the later one sees what the earlier one did
+type Combiner[k comparable, v any] struct {
+	b *Bowl[k, v]
+
+	// mu guards pending and combining
+	mu        sync.Mutex
+	pending   []*combineRequest[k, v]
+	combining bool
+}
+
+type combineRequest[k comparable, v any] struct {
+	ops  []batchOp[k, v]
+	errs []error
+	// done is closed once errs is ready, or this request should be the combiner
+	done    chan struct{}
+	combine bool
+}
+
+// NewCombiner creates a Combiner writing into b.
+// b can still be used directly at the same time
+func NewCombiner[k comparable, v any](b *Bowl[k, v]) *Combiner[k, v] {
+	return &Combiner[k, v]{b: b}
+}
+
+// Insert inserts all items, failing those whose key already exists, just like Bowl.Insert
+func (c *Combiner[k, v]) Insert(ihs []Item[k, v]) []error {
+	if err := c.b.validateItems(ihs); err != nil {
+		return fillErrors(make([]error, len(ihs)), err)
+	}
+	return c.submitItems(OP_INSERT, ihs)
+}
+
+// Update updates ih[i].Value when mathing ih[i].Key found, just like Bowl.Update
+func (c *Combiner[k, v]) Update(ihs []Item[k, v]) []error {
+	if err := c.b.validateItems(ihs); err != nil {
+		return fillErrors(make([]error, len(ihs)), err)
+	}
+	return c.submitItems(OP_UPDATE, ihs)
+}
+
+// Delete removes all matching keys, just like Bowl.Delete
+func (c *Combiner[k, v]) Delete(keys []k) []error {
+	if err := c.b.validateKeys(keys); err != nil {
+		return fillErrors(make([]error, len(keys)), err)
+	}
+	ops := make([]batchOp[k, v], len(keys))
+	for i, key := range keys {
+		ops[i] = batchOp[k, v]{opType: OP_DELETE, item: Item[k, v]{Key: key}, index: i}
+	}
+	return c.submit(ops, make([]error, len(keys)))
+}
+
+// Write applies all operations in wb together, just like Bowl.Write
+func (c *Combiner[k, v]) Write(wb *WriteBatch[k, v]) []error {
+	errs := make([]error, wb.Len())
+	return c.submit(wb.sortedOps(c.b.cmp, errs), errs)
+}
+
+func (c *Combiner[k, v]) submitItems(opType OpType, ihs []Item[k, v]) []error {
+	ops := make([]batchOp[k, v], len(ihs))
+	for i, ih := range ihs {
+		ops[i] = batchOp[k, v]{opType: opType, item: ih, index: i}
+	}
+	return c.submit(ops, make([]error, len(ihs)))
+}
+
+// submit queues ops, and waits until they are applied, either by this caller or another one.
+// errs may already have errors of ops not queued, they are kept
+func (c *Combiner[k, v]) submit(ops []batchOp[k, v], errs []error) []error {
+	if len(ops) == 0 {
+		return errs
+	}
+	req := &combineRequest[k, v]{ops: ops, errs: errs, done: make(chan struct{})}
+
+	c.mu.Lock()
+	c.pending = append(c.pending, req)
+	isCombiner := !c.combining
+	if isCombiner {
+		c.combining = true
+		req.combine = true
+	}
+	c.mu.Unlock()
+
+	if !isCombiner {
+		<-req.done
+		if !req.combine {
+			return req.errs
+		}
+	}
+	c.combine()
+	return req.errs
+}
+
+// combine applies the pending batches, round after round, until there are none.
+// After MAX_COMBINE_ROUNDS, it hands the role to the first waiting writer, so no one combines forever
+func (c *Combiner[k, v]) combine() {
+	for round := 0; ; round++ {
+		c.mu.Lock()
+		batch := c.pending
+		c.pending = nil
+		if len(batch) == 0 {
+			c.combining = false
+			c.mu.Unlock()
+			return
+		}
+		if round == MAX_COMBINE_ROUNDS {
+			// batch[0] is not applied yet, so it never is the caller itself
+			c.pending = batch
+			next := batch[0]
+			next.combine = true
+			c.mu.Unlock()
+			close(next.done)
+			return
+		}
+		c.mu.Unlock()
+
+		c.apply(batch)
+		for _, req := range batch {
+			if !req.combine {
+				close(req.done)
+			}
+		}
+	}
+}
+
+// apply merges the batches into a single sorted pass, under a single lock.
+// Ops with the same key are kept in the order they were queued
+func (c *Combiner[k, v]) apply(batch []*combineRequest[k, v]) {
+	total := 0
+	for _, req := range batch {
+		total += len(req.errs)
+	}
+	ops := make([]batchOp[k, v], 0, total)
+	errs := make([]error, 0, total)
+	for _, req := range batch {
+		offset := len(errs)
+		for _, op := range req.ops {
+			op.index += offset
+			ops = append(ops, op)
+		}
+		errs = append(errs, req.errs...)
+	}
+	slices.SortStableFunc(ops, func(a, b batchOp[k, v]) int {
+		return c.b.cmp(a.item.Key, b.item.Key)
+	})
+
+	c.b.Lock()
+	if release, err := c.b.logOps(WAL_RECORD_BATCH, len(ops), func(i int) batchOp[k, v] { return ops[i] }); err != nil {
+		fillErrors(errs, err)
+	} else {
+		c.b.structureVersion.Add(1)
+		c.b.applyOps(ops, errs)
+		release()
+	}
+	c.b.Unlock()
+
+	offset := 0
+	for _, req := range batch {
+		offset += copy(req.errs, errs[offset:])
+	}
+}
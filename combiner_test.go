@@ -0,0 +1,138 @@
+package bowl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCombinerSameKeyInOrder(t *testing.T) {
+	// batches combined together should behave as if applied one after another
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(4))
+	c := NewCombiner(b)
+	c.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}})
+
+	del := &combineRequest[int, int]{errs: make([]error, 2), done: make(chan struct{})}
+	del.ops = []batchOp[int, int]{
+		{opType: OP_DELETE, item: Item[int, int]{Key: 1}, index: 0},
+		{opType: OP_DELETE, item: Item[int, int]{Key: 2}, index: 1},
+	}
+	ins := &combineRequest[int, int]{errs: make([]error, 2), done: make(chan struct{})}
+	ins.ops = []batchOp[int, int]{
+		{opType: OP_INSERT, item: Item[int, int]{Key: 2, Value: 20}, index: 0},
+		{opType: OP_INSERT, item: Item[int, int]{Key: 3, Value: 30}, index: 1},
+	}
+	upd := &combineRequest[int, int]{errs: make([]error, 1), done: make(chan struct{})}
+	upd.ops = []batchOp[int, int]{
+		{opType: OP_UPDATE, item: Item[int, int]{Key: 1, Value: 10}, index: 0},
+	}
+	c.apply([]*combineRequest[int, int]{del, ins, upd})
+
+	for name, errs := range map[string][]error{"delete": del.errs, "insert": ins.errs} {
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("%s at %d should succeed, but instead we got %v", name, i, err)
+			}
+		}
+	}
+	if upd.errs[0] != ErrDataNotFound {
+		t.Fatalf("Update of key deleted by an earlier batch should fail, but instead we got %v", upd.errs[0])
+	}
+	checkSameContent(t, "combined", []Item[int, int]{{Key: 2, Value: 20}, {Key: 3, Value: 30}}, snapshotContent(b))
+	checkBowlLinks(t, b)
+}
+
+func TestCombinerConcurrentWriters(t *testing.T) {
+	// run with -race, every key is inserted by 2 writers, and deleted by 2 others.
+	// Each should succeed exactly as many times as the other kind did before it
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(16))
+	c := NewCombiner(b)
+	const writers = 16
+	const keys = 4000
+
+	results := make([][]error, writers)
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w / 4; i < keys; i += 40 {
+				ihs := make([]Item[int, int], 0, 10)
+				for j := i; j < i+40 && j < keys; j += 4 {
+					ihs = append(ihs, Item[int, int]{Key: j, Value: w})
+				}
+				switch w % 4 {
+				case 0, 1:
+					results[w] = append(results[w], c.Insert(ihs)...)
+				default:
+					ks := make([]int, len(ihs))
+					for j, ih := range ihs {
+						ks[j] = ih.Key
+					}
+					results[w] = append(results[w], c.Delete(ks)...)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	inserted, deleted := 0, 0
+	for w, errs := range results {
+		for _, err := range errs {
+			if err == nil && w%4 < 2 {
+				inserted++
+			} else if err == nil {
+				deleted++
+			} else if err != ErrKeyAlreadyExist && err != ErrDataNotFound && err != ErrNodeIsEmpty {
+				t.Fatalf("It should only fail for existing or missing key, but instead we got %v", err)
+			}
+		}
+	}
+	if left := len(snapshotContent(b)); inserted-deleted != left {
+		t.Fatalf("It should have %d data left, from %d inserted and %d deleted, but instead we got %d",
+			inserted-deleted, inserted, deleted, left)
+	}
+	checkBowlLinks(t, b)
+}
+
+func TestCombinerStrictValidation(t *testing.T) {
+	c := NewCombiner(NewBOWL[int, int](cmpTest, WithStrictValidation()))
+	for _, err := range c.Delete([]int{2, 1}) {
+		if err != (ErrUnsortedBatch{Index: 1}) {
+			t.Fatalf("Unsorted batch should fail, but instead we got %v", err)
+		}
+	}
+}
+
+// BenchmarkCombiner compares 64 writers with small batches,
+// inserting directly against going through a Combiner
+func BenchmarkCombiner(b *testing.B) {
+	const writers = 64
+	const batchSize = 16
+	for _, name := range []string{"direct", "combined"} {
+		b.Run(fmt.Sprintf("%s/writers=%d", name, writers), func(b *testing.B) {
+			bowl := NewBOWL[int, int](cmpTest)
+			insert := bowl.Insert
+			if name == "combined" {
+				insert = NewCombiner(bowl).Insert
+			}
+
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					data := make([]Item[int, int], batchSize)
+					for i := w; i < b.N; i += writers {
+						for j := range data {
+							key := (i*batchSize+j)*writers + w
+							data[j] = Item[int, int]{Key: key, Value: key}
+						}
+						insert(data)
+					}
+				}(w)
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package bowl
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// LOCK_BACKOFF_MIN is the first wait of the ...Ctx operations, when the lock is not available
+	LOCK_BACKOFF_MIN time.Duration = time.Microsecond
+	// LOCK_BACKOFF_MAX is the longest wait between 2 tries, the wait doubles until it
+	LOCK_BACKOFF_MAX time.Duration = time.Millisecond
+)
+
+// acquireCtx calls try until it succeeds, waiting longer and longer in between,
+// or returns ctx.Err() once ctx is done. try is always called at least once,
+// so an already done ctx makes it a non-blocking try-lock
+func acquireCtx(ctx context.Context, try func() bool) error {
+	if try() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	backoff := LOCK_BACKOFF_MIN
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if try() {
+			return nil
+		}
+		backoff = min(backoff*2, LOCK_BACKOFF_MAX)
+		timer.Reset(backoff)
+	}
+}
+
+// rLockCtx takes the read lock, or returns ctx.Err() if it can not before ctx is done
+func (b *Bowl[k, v]) rLockCtx(ctx context.Context) error {
+	return acquireCtx(ctx, b.TryRLock)
+}
+
+// writeLockCtx takes what writeLock takes, or returns ctx.Err() if it can not before ctx is done
+func (b *Bowl[k, v]) writeLockCtx(ctx context.Context) error {
+	if b.lockingMode == LOCKING_PER_NODE {
+		return acquireCtx(ctx, b.TryRLock)
+	}
+	return acquireCtx(ctx, b.TryLock)
+}
+
+// GetCtx is Get, but gives up with ctx.Err() when the lock can not be taken before ctx is done.
+// The validation error of WithStrictValidation is returned, just like TryGet
+//
+// The lock is always tried once, even when ctx is already done, so an already canceled ctx
+// makes GetCtx, InsertCtx, UpdateCtx, and DeleteCtx non-blocking try-lock variants.
+//
+// Note that the lock is only tried, so a steady stream of other operations may keep it busy
+// until ctx is done. Once taken, the batch is done fully, ctx is not checked anymore
+func (b *Bowl[k, v]) GetCtx(ctx context.Context, keys []k, notFoundDefaultValue v) ([]v, error) {
+	if err := b.validateKeys(keys); err != nil {
+		return nil, err
+	}
+	result := make([]v, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	if err := b.rLockCtx(ctx); err != nil {
+		return nil, err
+	}
+	defer b.RUnlock()
+	b.get(keys, notFoundDefaultValue, result)
+	return result, nil
+}
+
+// InsertCtx is Insert, but gives up with ctx.Err() when the lock can not be taken before ctx is done,
+// in which case nothing is inserted
+//
+// Once the lock is taken, the batch is done fully, ctx is not checked anymore
+func (b *Bowl[k, v]) InsertCtx(ctx context.Context, ihs []Item[k, v]) ([]error, error) {
+	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err), nil
+	}
+	if len(ihs) == 0 {
+		return errs, nil
+	}
+
+	if err := b.writeLockCtx(ctx); err != nil {
+		return nil, err
+	}
+	defer b.writeUnlock()
+	b.insert(ihs, errs)
+	return errs, nil
+}
+
+// UpdateCtx is Update, but gives up with ctx.Err() when the lock can not be taken before ctx is done,
+// in which case nothing is updated
+//
+// Once the lock is taken, the batch is done fully, ctx is not checked anymore
+func (b *Bowl[k, v]) UpdateCtx(ctx context.Context, ihs []Item[k, v]) ([]error, error) {
+	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err), nil
+	}
+	if len(ihs) == 0 {
+		return errs, nil
+	}
+
+	if err := b.writeLockCtx(ctx); err != nil {
+		return nil, err
+	}
+	defer b.writeUnlock()
+	b.update(ihs, errs)
+	return errs, nil
+}
+
+// DeleteCtx is Delete, but gives up with ctx.Err() when the lock can not be taken before ctx is done,
+// in which case nothing is deleted
+//
+// Once the lock is taken, the batch is done fully, ctx is not checked anymore
+func (b *Bowl[k, v]) DeleteCtx(ctx context.Context, keys []k) ([]error, error) {
+	errs := make([]error, len(keys))
+	if err := b.validateKeys(keys); err != nil {
+		return fillErrors(errs, err), nil
+	}
+	if len(keys) == 0 {
+		return errs, nil
+	}
+
+	if err := b.writeLockCtx(ctx); err != nil {
+		return nil, err
+	}
+	defer b.writeUnlock()
+	b.delete(keys, errs)
+	return errs, nil
+}
+
+// ScanAllCtx is ScanAll, see ScanBoundsWhileCtx
+func (b *Bowl[k, v]) ScanAllCtx(ctx context.Context, fn func(Item[k, v])) error {
+	return b.ScanBoundsWhileCtx(ctx, Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanRangeCtx is ScanRange, see ScanBoundsWhileCtx
+func (b *Bowl[k, v]) ScanRangeCtx(ctx context.Context, fromKey k, toKey k, fn func(Item[k, v])) error {
+	return b.ScanBoundsWhileCtx(ctx,
+		Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanBoundsWhileCtx is ScanBoundsWhile, but gives up with ctx.Err()
+// when the lock can not be taken before ctx is done.
+// ctx is also checked before each node, so a long scan stops with ctx.Err() soon after ctx is done,
+// having passed only part of the data to fn. So unlike GetCtx, an already done ctx passes nothing
+func (b *Bowl[k, v]) ScanBoundsWhileCtx(ctx context.Context, bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	if err := b.rLockCtx(ctx); err != nil {
+		return err
+	}
+	defer b.RUnlock()
+	return b.scanBounds(ctx, bounds, fn)
+}
+
+// ReverseScanAllCtx is ReverseScanAll, see ScanBoundsWhileCtx
+func (b *Bowl[k, v]) ReverseScanAllCtx(ctx context.Context, fn func(Item[k, v])) error {
+	return b.ReverseScanBoundsWhileCtx(ctx, Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ReverseScanRangeCtx is ReverseScanRange, see ScanBoundsWhileCtx
+func (b *Bowl[k, v]) ReverseScanRangeCtx(ctx context.Context, fromKey k, toKey k, fn func(Item[k, v])) error {
+	return b.ReverseScanBoundsWhileCtx(ctx,
+		Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ReverseScanBoundsWhileCtx is ReverseScanBoundsWhile, see ScanBoundsWhileCtx
+func (b *Bowl[k, v]) ReverseScanBoundsWhileCtx(
+	ctx context.Context, bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	if err := b.rLockCtx(ctx); err != nil {
+		return err
+	}
+	defer b.RUnlock()
+	return b.reverseScanBounds(ctx, bounds, fn)
+}
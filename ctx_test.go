@@ -0,0 +1,155 @@
+package bowl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBowlCtxGivesUpOnLock(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode))
+		b.Insert([]Item[int, int]{{Key: 1, Value: 1}})
+
+		// as if a long exclusive operation is running
+		b.Lock()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if _, err := b.GetCtx(ctx, []int{1}, 0); err != context.DeadlineExceeded {
+			t.Fatalf("%s: GetCtx should give up, but instead we got %v", name, err)
+		}
+		if _, err := b.InsertCtx(ctx, []Item[int, int]{{Key: 2, Value: 2}}); err != context.DeadlineExceeded {
+			t.Fatalf("%s: InsertCtx should give up, but instead we got %v", name, err)
+		}
+		if _, err := b.UpdateCtx(ctx, []Item[int, int]{{Key: 1, Value: 10}}); err != context.DeadlineExceeded {
+			t.Fatalf("%s: UpdateCtx should give up, but instead we got %v", name, err)
+		}
+		if _, err := b.DeleteCtx(ctx, []int{1}); err != context.DeadlineExceeded {
+			t.Fatalf("%s: DeleteCtx should give up, but instead we got %v", name, err)
+		}
+		if err := b.ScanAllCtx(ctx, func(Item[int, int]) {}); err != context.DeadlineExceeded {
+			t.Fatalf("%s: ScanAllCtx should give up, but instead we got %v", name, err)
+		}
+		cancel()
+		b.Unlock()
+
+		checkSameContent(t, name, []Item[int, int]{{Key: 1, Value: 1}}, snapshotContent(b))
+	}
+}
+
+func TestBowlCtxDoneTriesLockOnce(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// the lock is free, so an already canceled ctx still gets it
+		errs, err := b.InsertCtx(ctx, []Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}})
+		if err != nil || errs[0] != nil || errs[1] != nil {
+			t.Fatalf("%s: InsertCtx with a free lock should succeed, but instead we got %v %v", name, err, errs)
+		}
+		if errs, err = b.UpdateCtx(ctx, []Item[int, int]{{Key: 1, Value: 10}}); err != nil || errs[0] != nil {
+			t.Fatalf("%s: UpdateCtx with a free lock should succeed, but instead we got %v %v", name, err, errs)
+		}
+		if errs, err = b.DeleteCtx(ctx, []int{2}); err != nil || errs[0] != nil {
+			t.Fatalf("%s: DeleteCtx with a free lock should succeed, but instead we got %v %v", name, err, errs)
+		}
+		if values, err := b.GetCtx(ctx, []int{1, 2}, -1); err != nil || values[0] != 10 || values[1] != -1 {
+			t.Fatalf("%s: GetCtx with a free lock should return 10, -1, but instead we got %v %v", name, err, values)
+		}
+
+		// the lock is busy, so it fails right away, without waiting
+		b.Lock()
+		start := time.Now()
+		if _, err := b.GetCtx(ctx, []int{1}, -1); err != context.Canceled {
+			t.Fatalf("%s: GetCtx with a busy lock should fail with context.Canceled, but instead we got %v", name, err)
+		}
+		if _, err := b.InsertCtx(ctx, []Item[int, int]{{Key: 3, Value: 3}}); err != context.Canceled {
+			t.Fatalf("%s: InsertCtx with a busy lock should fail with context.Canceled, but instead we got %v", name, err)
+		}
+		if _, err := b.UpdateCtx(ctx, []Item[int, int]{{Key: 1, Value: 100}}); err != context.Canceled {
+			t.Fatalf("%s: UpdateCtx with a busy lock should fail with context.Canceled, but instead we got %v", name, err)
+		}
+		if _, err := b.DeleteCtx(ctx, []int{1}); err != context.Canceled {
+			t.Fatalf("%s: DeleteCtx with a busy lock should fail with context.Canceled, but instead we got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Fatalf("%s: Busy lock with a canceled ctx should fail right away, but instead it took %v", name, elapsed)
+		}
+		b.Unlock()
+
+		checkSameContent(t, name, []Item[int, int]{{Key: 1, Value: 10}}, snapshotContent(b))
+	}
+}
+
+func TestBowlCtxWaitsForLock(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode))
+		b.Lock()
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			b.Unlock()
+		}()
+
+		errs, err := b.InsertCtx(context.Background(), []Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}})
+		if err != nil || errs[0] != nil || errs[1] != nil {
+			t.Fatalf("%s: InsertCtx should succeed once the lock is free, but instead we got %v %v", name, err, errs)
+		}
+		errs, err = b.UpdateCtx(context.Background(), []Item[int, int]{{Key: 1, Value: 10}, {Key: 3, Value: 30}})
+		if err != nil || errs[0] != nil || errs[1] != ErrDataNotFound {
+			t.Fatalf("%s: UpdateCtx should only fail for missing key, but instead we got %v %v", name, err, errs)
+		}
+		errs, err = b.DeleteCtx(context.Background(), []int{2})
+		if err != nil || errs[0] != nil {
+			t.Fatalf("%s: DeleteCtx should succeed, but instead we got %v %v", name, err, errs)
+		}
+		values, err := b.GetCtx(context.Background(), []int{1, 2}, -1)
+		if err != nil || values[0] != 10 || values[1] != -1 {
+			t.Fatalf("%s: GetCtx should return [10 -1], but instead we got %v %v", name, values, err)
+		}
+	}
+}
+
+func TestBowlScanCtxStopsBetweenNodes(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(8))
+		ihs := make([]Item[int, int], 0, 1000)
+		for i := 0; i < 1000; i++ {
+			ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+		}
+		b.Insert(ihs)
+
+		scans := map[string]func(ctx context.Context, fn func(Item[int, int])) error{
+			"forward":  b.ScanAllCtx,
+			"backward": b.ReverseScanAllCtx,
+			"range": func(ctx context.Context, fn func(Item[int, int])) error {
+				return b.ScanRangeCtx(ctx, 100, 900, fn)
+			},
+			"reverse range": func(ctx context.Context, fn func(Item[int, int])) error {
+				return b.ReverseScanRangeCtx(ctx, 100, 900, fn)
+			},
+		}
+		for scanName, scan := range scans {
+			ctx, cancel := context.WithCancel(context.Background())
+			count := 0
+			err := scan(ctx, func(Item[int, int]) {
+				count++
+				cancel()
+			})
+			if err != context.Canceled || count == 0 || count > 8 {
+				t.Fatalf("%s %s: scan should stop within the first node, but instead we got %v after %d data",
+					name, scanName, err, count)
+			}
+
+			count = 0
+			err = scan(context.Background(), func(Item[int, int]) { count++ })
+			if err != nil || count < 801 {
+				t.Fatalf("%s %s: scan should go through, but instead we got %v after %d data", name, scanName, err, count)
+			}
+		}
+
+		// the scan should let go of everything it held
+		if errs := b.Delete([]int{0}); errs[0] != nil {
+			t.Fatalf("%s: Delete after cancelled scans should succeed, but instead we got %v", name, errs[0])
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package bowl
+
+// Cursor walks a Bowl one item at a time, in either direction
+//
+// Unlike the scans, the Bowl's read lock is only held during each step,
+// so writers can run in between steps. The cursor remembers where it is by key.
+// If `Insert` or `Delete` (including any `SplitIntoNewNode` they cause) ran since the last step,
+// the cursor first finds its position again from that key, before moving.
+// That means `Next` always lands on the smallest key strictly greater than the current one,
+// and `Prev` on the biggest key strictly less than it, as of the time of the step.
+//
+// `Key` and `Value` return the copy taken when the cursor got positioned,
+// which may already be updated or deleted by the time they are called.
+//
+// A Cursor itself is not goroutine-safe
+type Cursor[k comparable, v any] struct {
+	b     *Bowl[k, v]
+	node  *Node[k, v]
+	pos   int
+	item  Item[k, v]
+	valid bool
+
+	// structureVersion of the Bowl when this cursor got positioned
+	structureVersion uint64
+}
+
+// NewCursor creates a Cursor over b, which is not positioned yet.
+// Call `First`, `Last` or `Seek` before anything else
+func (b *Bowl[k, v]) NewCursor() *Cursor[k, v] {
+	return &Cursor[k, v]{b: b}
+}
+
+// Valid returns whether the cursor is positioned at an item
+func (c *Cursor[k, v]) Valid() bool {
+	return c.valid
+}
+
+// Key returns the key of the current item. Only meaningful when `Valid` is true
+func (c *Cursor[k, v]) Key() k {
+	return c.item.Key
+}
+
+// Value returns the value of the current item. Only meaningful when `Valid` is true
+func (c *Cursor[k, v]) Value() v {
+	return c.item.Value
+}
+
+// First positions the cursor at the smallest key, and returns whether there is one
+func (c *Cursor[k, v]) First() bool {
+	c.b.readLock()
+	defer c.b.readUnlock()
+
+	node := c.b.getValidNodeToStartScan()
+	if node == nil {
+		return c.invalidate()
+	}
+	return c.settleForward(node, 0)
+}
+
+// Last positions the cursor at the biggest key, and returns whether there is one
+func (c *Cursor[k, v]) Last() bool {
+	c.b.readLock()
+	defer c.b.readUnlock()
+
+	node := c.b.getLastNode()
+	if node == nil {
+		return c.invalidate()
+	}
+	return c.settleBackward(node, node.GetCount()-1)
+}
+
+// Seek positions the cursor at the smallest key greater than or equal to `key`,
+// and returns whether there is one
+func (c *Cursor[k, v]) Seek(key k) bool {
+	c.b.readLock()
+	defer c.b.readUnlock()
+
+	return c.seekGreaterThanEqual(key)
+}
+
+// Next moves the cursor to the next bigger key, and returns whether there is one.
+// Once it returns false, the cursor is no longer valid
+func (c *Cursor[k, v]) Next() bool {
+	if !c.valid {
+		return false
+	}
+	c.b.readLock()
+	defer c.b.readUnlock()
+
+	if c.structureVersion != c.b.structureVersion.Load() {
+		return c.seekStrictlyGreaterThan(c.item.Key)
+	}
+	return c.settleForward(c.node, c.pos+1)
+}
+
+// Prev moves the cursor to the next smaller key, and returns whether there is one.
+// Once it returns false, the cursor is no longer valid
+func (c *Cursor[k, v]) Prev() bool {
+	if !c.valid {
+		return false
+	}
+	c.b.readLock()
+	defer c.b.readUnlock()
+
+	if c.structureVersion != c.b.structureVersion.Load() {
+		return c.seekStrictlyLessThan(c.item.Key)
+	}
+	return c.settleBackward(c.node, c.pos-1)
+}
+
+func (c *Cursor[k, v]) invalidate() bool {
+	c.node = nil
+	c.valid = false
+	return false
+}
+
+func (c *Cursor[k, v]) seekGreaterThanEqual(key k) bool {
+	node := c.b.getNodeForRead(key)
+	if node == nil {
+		return c.invalidate()
+	}
+	return c.settleForward(node, node.getPositionGreaterThanEqualBinary(key))
+}
+
+func (c *Cursor[k, v]) seekStrictlyGreaterThan(key k) bool {
+	node := c.b.getNodeForRead(key)
+	if node == nil {
+		return c.invalidate()
+	}
+	return c.settleForward(node, node.getPositionStrictlyGreaterThan(key))
+}
+
+func (c *Cursor[k, v]) seekStrictlyLessThan(key k) bool {
+	node := c.b.getNodeForRead(key)
+	if node == nil {
+		return c.invalidate()
+	}
+	return c.settleBackward(node, node.getPositionGreaterThanEqualBinary(key)-1)
+}
+
+// settleForward positions the cursor at `pos` in `node`,
+// or at the first item of the following nodes when `pos` is past this node's data
+func (c *Cursor[k, v]) settleForward(node *Node[k, v], pos int) bool {
+	for pos >= node.GetCount() {
+		node = c.b.getNextNodeAtHeightWithData(0, node)
+		if node == nil {
+			return c.invalidate()
+		}
+		pos = 0
+	}
+	return c.positionAt(node, pos)
+}
+
+// settleBackward positions the cursor at `pos` in `node`,
+// or at the last item of the preceding nodes when `pos` is before this node's data
+func (c *Cursor[k, v]) settleBackward(node *Node[k, v], pos int) bool {
+	for pos < 0 {
+		node = c.b.getPrevNodeNotMarkedRemoval(node)
+		if node == nil {
+			return c.invalidate()
+		}
+		pos = node.GetCount() - 1
+	}
+	return c.positionAt(node, pos)
+}
+
+func (c *Cursor[k, v]) positionAt(node *Node[k, v], pos int) bool {
+	c.node = node
+	c.pos = pos
+	c.item = node.data[pos]
+	c.valid = true
+	c.structureVersion = c.b.structureVersion.Load()
+	return true
+}
@@ -0,0 +1,164 @@
+package bowl
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+
+	c := b.NewCursor()
+	if c.First() || c.Last() || c.Seek(10) || c.Valid() {
+		t.Fatal("Cursor on empty Bowl should never be valid, but it is")
+	}
+
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 2, Value: i * 4})
+	}
+	b.Insert(ihs)
+
+	count := 0
+	for ok := c.First(); ok; ok = c.Next() {
+		if c.Key() != count*2 || c.Value() != count*4 {
+			t.Fatalf("At iter %d, it should be %d/%d, but instead we got %d/%d", count, count*2, count*4, c.Key(), c.Value())
+		}
+		count++
+	}
+	if count != 1000 || c.Valid() {
+		t.Fatalf("It should walk all 1000 data then be invalid, but instead we got %d and valid: %v", count, c.Valid())
+	}
+	if c.Next() || c.Prev() {
+		t.Fatal("Next and Prev should return false once cursor is invalid, but it is not")
+	}
+
+	count = 0
+	for ok := c.Last(); ok; ok = c.Prev() {
+		if c.Key() != (999-count)*2 {
+			t.Fatalf("At iter %d, it should be %d, but instead we got %d", count, (999-count)*2, c.Key())
+		}
+		count++
+	}
+	if count != 1000 {
+		t.Fatalf("It should walk all 1000 data backward, but instead we got %d", count)
+	}
+
+	if !c.Seek(501) || c.Key() != 502 {
+		t.Fatalf("Seek(501) should land on 502, but instead we got %d", c.Key())
+	}
+	if !c.Seek(502) || c.Key() != 502 {
+		t.Fatalf("Seek(502) should land on 502, but instead we got %d", c.Key())
+	}
+	if !c.Prev() || c.Key() != 500 {
+		t.Fatalf("Prev from 502 should land on 500, but instead we got %d", c.Key())
+	}
+	if c.Seek(1999) {
+		t.Fatalf("Seek(1999) should be invalid as it is past the biggest key, but instead we got %d", c.Key())
+	}
+
+	// writers in between steps
+	c.Seek(1000)
+	b.Delete([]int{1000, 1002})
+	if !c.Next() || c.Key() != 1004 {
+		t.Fatalf("Next should skip deleted keys and land on 1004, but instead we got %d", c.Key())
+	}
+	b.Insert([]Item[int, int]{{Key: 1001, Value: 1}, {Key: 1005, Value: 1}})
+	if !c.Next() || c.Key() != 1005 {
+		t.Fatalf("Next should see newly inserted 1005, but instead we got %d", c.Key())
+	}
+	if !c.Prev() || c.Key() != 1004 {
+		t.Fatalf("Prev should land back on 1004, but instead we got %d", c.Key())
+	}
+	if !c.Prev() || c.Key() != 1001 {
+		t.Fatalf("Prev should see newly inserted 1001, but instead we got %d", c.Key())
+	}
+
+	// many inserts right after the cursor force nodes to split
+	ihs = ihs[:0]
+	for i := 0; i < 600; i++ {
+		ihs = append(ihs, Item[int, int]{Key: 1001 + (i+1)*2, Value: 7})
+	}
+	errs := b.Insert(ihs)
+	inserted := 0
+	for _, err := range errs {
+		if err == nil {
+			inserted++
+		}
+	}
+	count = 0
+	prev := c.Key()
+	for c.Next() {
+		if c.Key() <= prev {
+			t.Fatalf("Next should be strictly increasing, but we got %d after %d", c.Key(), prev)
+		}
+		prev = c.Key()
+		count++
+	}
+	// keys after 1001 were 1004..1998 (498 of them) plus 1005, plus the newly inserted ones
+	if count != 499+inserted {
+		t.Fatalf("It should walk %d data after 1001, but instead we got %d", 499+inserted, count)
+	}
+}
+
+func TestCursorAcrossSmallNodes(t *testing.T) {
+	// every node holds at most 2 data, so almost every step crosses a node boundary
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(2))
+	ihs := make([]Item[int, int], 0, 20)
+	for i := 0; i < 20; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+	}
+	b.Insert(ihs)
+
+	c := b.NewCursor()
+	count := 0
+	for ok := c.First(); ok; ok = c.Next() {
+		if c.Key() != count {
+			t.Fatalf("Next across nodes should land on %d, but instead we got %d", count, c.Key())
+		}
+		count++
+	}
+	if count != 20 {
+		t.Fatalf("It should walk all 20 data forward, but instead we got %d", count)
+	}
+	count = 0
+	for ok := c.Last(); ok; ok = c.Prev() {
+		if c.Key() != 19-count {
+			t.Fatalf("Prev across nodes should land on %d, but instead we got %d", 19-count, c.Key())
+		}
+		count++
+	}
+	if count != 20 {
+		t.Fatalf("It should walk all 20 data backward, but instead we got %d", count)
+	}
+
+	// emptying whole nodes on both sides of the cursor
+	c.Seek(10)
+	b.Delete([]int{4, 5, 6, 7, 8, 9})
+	if !c.Prev() || c.Key() != 3 {
+		t.Fatalf("Prev should skip the emptied nodes and land on 3, but instead we got %d", c.Key())
+	}
+	b.Delete([]int{10, 11, 12, 13})
+	if !c.Next() || c.Key() != 14 {
+		t.Fatalf("Next should skip the emptied nodes and land on 14, but instead we got %d", c.Key())
+	}
+
+	// the cursor's own data deleted, together with its whole node
+	b.Delete([]int{14, 15})
+	if !c.Next() || c.Key() != 16 {
+		t.Fatalf("Next from a deleted key should land on 16, but instead we got %d", c.Key())
+	}
+	b.Delete([]int{16, 17})
+	if !c.Prev() || c.Key() != 3 {
+		t.Fatalf("Prev from a deleted key should land on 3, but instead we got %d", c.Key())
+	}
+
+	// everything after the cursor gone
+	b.Delete([]int{18, 19})
+	if c.Next() || c.Valid() {
+		t.Fatalf("Next past the last data should be invalid, but instead we got %d", c.Key())
+	}
+	if !c.Last() || c.Key() != 3 {
+		t.Fatalf("Last should land on 3, but instead we got %d", c.Key())
+	}
+	checkBowlLinks(t, b)
+}
@@ -0,0 +1,52 @@
+package bowl
+
+import (
+	"iter"
+)
+
+// The iterators below are built on top of the `...While` scans,
+// so the Bowl's read lock is held for the whole loop.
+// The loop body must NOT call back into the same Bowl, or it deadlocks
+// (writes wait for the loop, and reads wait behind any pending write).
+// Use Cursor when the consumer needs to do so.
+
+// All returns an iterator over every key-value pair, in ascending order
+func (b *Bowl[k, v]) All() iter.Seq2[k, v] {
+	return func(yield func(k, v) bool) {
+		b.ScanAllWhile(yieldItem(yield))
+	}
+}
+
+// Range returns an iterator over every key-value pair with fromKey <= key < toKey, in ascending order
+func (b *Bowl[k, v]) Range(fromKey, toKey k) iter.Seq2[k, v] {
+	return func(yield func(k, v) bool) {
+		b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Exclusive(toKey)}, yieldItem(yield))
+	}
+}
+
+// From returns an iterator over every key-value pair with key >= `key`, in ascending order
+func (b *Bowl[k, v]) From(key k) iter.Seq2[k, v] {
+	return func(yield func(k, v) bool) {
+		b.ScanGreaterThanEqualWhile(key, yieldItem(yield))
+	}
+}
+
+// Before returns an iterator over every key-value pair with key < `key`, in ascending order
+func (b *Bowl[k, v]) Before(key k) iter.Seq2[k, v] {
+	return func(yield func(k, v) bool) {
+		b.ScanStrictlyLessThanWhile(key, yieldItem(yield))
+	}
+}
+
+// Backward returns an iterator over every key-value pair, in descending order
+func (b *Bowl[k, v]) Backward() iter.Seq2[k, v] {
+	return func(yield func(k, v) bool) {
+		b.ReverseScanAllWhile(yieldItem(yield))
+	}
+}
+
+func yieldItem[k comparable, v any](yield func(k, v) bool) func(Item[k, v]) bool {
+	return func(ih Item[k, v]) bool {
+		return yield(ih.Key, ih.Value)
+	}
+}
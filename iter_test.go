@@ -0,0 +1,132 @@
+package bowl
+
+import (
+	"iter"
+	"testing"
+	"time"
+)
+
+func TestBowlIterators(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i, Value: i * 10})
+	}
+	b.Insert(ihs)
+
+	collect := func(seq iter.Seq2[int, int], limit int) []int {
+		keys := make([]int, 0)
+		for key, val := range seq {
+			if val != key*10 {
+				t.Fatalf("Value for key %d should be %d, but instead we got %d", key, key*10, val)
+			}
+			if len(keys) == limit {
+				break
+			}
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	keys := collect(b.All(), -1)
+	if len(keys) != 1000 || keys[0] != 0 || keys[999] != 999 {
+		t.Fatalf("All should yield 0 to 999, but instead we got %d keys", len(keys))
+	}
+	keys = collect(b.Range(100, 200), -1)
+	if len(keys) != 100 || keys[0] != 100 || keys[99] != 199 {
+		t.Fatalf("Range should yield 100 to 199, but instead we got %d keys", len(keys))
+	}
+	keys = collect(b.From(990), -1)
+	if len(keys) != 10 || keys[0] != 990 {
+		t.Fatalf("From should yield 990 to 999, but instead we got %v", keys)
+	}
+	keys = collect(b.Before(10), -1)
+	if len(keys) != 10 || keys[9] != 9 {
+		t.Fatalf("Before should yield 0 to 9, but instead we got %v", keys)
+	}
+	keys = collect(b.Backward(), -1)
+	if len(keys) != 1000 || keys[0] != 999 || keys[999] != 0 {
+		t.Fatalf("Backward should yield 999 down to 0, but instead we got %d keys", len(keys))
+	}
+
+	// break in the middle of a node, the runtime panics if any scan keeps yielding
+	for _, seq := range []iter.Seq2[int, int]{
+		b.All(), b.Range(5, 900), b.From(3), b.Before(800), b.Backward(),
+	} {
+		keys = collect(seq, 7)
+		if len(keys) != 7 {
+			t.Fatalf("Should stop after 7 keys, but instead we got %v", keys)
+		}
+	}
+
+	// lock should already be released after breaking out
+	res := b.Get([]int{1, 2}, -1)
+	if res[0] != 10 || res[1] != 20 {
+		t.Fatalf("Should get 10 and 20, but instead we got %v", res)
+	}
+}
+
+func TestBowlIteratorsEarlyBreak(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(4))
+	for range b.All() {
+		t.Fatal("All on an empty Bowl should yield nothing, but it does")
+	}
+
+	ihs := make([]Item[int, int], 0, 40)
+	for i := 0; i < 40; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+	}
+	b.Insert(ihs)
+
+	// breaking after every possible count, so also right at the node boundaries
+	for limit := 1; limit <= 40; limit++ {
+		count := 0
+		for key := range b.All() {
+			if key != count {
+				t.Fatalf("All should yield %d, but instead we got %d", count, key)
+			}
+			count++
+			if count == limit {
+				break
+			}
+		}
+		if count != limit {
+			t.Fatalf("All should stop after %d keys, but instead we got %d", limit, count)
+		}
+		count = 0
+		for key := range b.Backward() {
+			if key != 39-count {
+				t.Fatalf("Backward should yield %d, but instead we got %d", 39-count, key)
+			}
+			count++
+			if count == limit {
+				break
+			}
+		}
+		if count != limit {
+			t.Fatalf("Backward should stop after %d keys, but instead we got %d", limit, count)
+		}
+	}
+	for range b.Range(100, 200) {
+		t.Fatal("Range outside the data should yield nothing, but it does")
+	}
+
+	// a pull iterator stopped in the middle releases the lock as well
+	next, stop := iter.Pull2(b.From(10))
+	if key, _, ok := next(); !ok || key != 10 {
+		t.Fatalf("Pull from 10 should yield 10 first, but instead we got %d", key)
+	}
+	stop()
+
+	// a writer needs the exclusive lock, so it only goes through when every break released it
+	done := make(chan struct{})
+	go func() {
+		b.Insert([]Item[int, int]{{Key: 100, Value: 100}})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Insert after breaking out should not block, but it does")
+	}
+}
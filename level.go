@@ -0,0 +1,45 @@
+package bowl
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// LevelGenerator decides the height of every new node.
+//
+// Each Bowl holds its own LevelGenerator, and calls it during writes,
+// which may come from many goroutines depending on the locking mode,
+// so implementations should be goroutine-safe
+type LevelGenerator interface {
+	// Level returns the height for a new node, between 1 and maxHeight (inclusive)
+	Level(maxHeight int) int
+}
+
+// RandomLevelGenerator is the default LevelGenerator, where each node
+// has probability `p` to get one more level than the previous, up to maxHeight
+type RandomLevelGenerator struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+	p   float64
+}
+
+// NewRandomLevelGenerator creates a RandomLevelGenerator seeded with `seed`.
+// Two generators with the same seed and `p` return the same levels
+func NewRandomLevelGenerator(seed int64, p float64) *RandomLevelGenerator {
+	return &RandomLevelGenerator{
+		rnd: rand.New(rand.NewSource(seed)),
+		p:   p,
+	}
+}
+
+// Level returns the height for a new node, safe to be called concurrently
+func (g *RandomLevelGenerator) Level(maxHeight int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	level := 1
+	for g.rnd.Float64() < g.p && level < maxHeight {
+		level++
+	}
+	return level
+}
@@ -0,0 +1,94 @@
+package bowl
+
+import (
+	"sync"
+	"testing"
+)
+
+type fixedLevelGenerator int
+
+func (g fixedLevelGenerator) Level(maxHeight int) int {
+	return int(g)
+}
+
+func insertAscending(b *Bowl[int, int], count int) {
+	for i := 0; i < count; i += 100 {
+		ihs := make([]Item[int, int], 0, 100)
+		for j := i; j < i+100 && j < count; j++ {
+			ihs = append(ihs, Item[int, int]{Key: j, Value: j})
+		}
+		b.Insert(ihs)
+	}
+}
+
+func nodeHeights(b *Bowl[int, int]) []int {
+	heights := make([]int, 0)
+	node, _ := b.head.GetNextNodeAt(0)
+	for node != nil {
+		heights = append(heights, node.GetHeight())
+		node, _ = node.GetNextNodeAt(0)
+	}
+	return heights
+}
+
+func TestBowlSeedIsReproducible(t *testing.T) {
+	b1 := NewBOWL[int, int](cmpTest, WithNodeSize(8), WithSeed(42))
+	b2 := NewBOWL[int, int](cmpTest, WithNodeSize(8), WithSeed(42))
+	insertAscending(b1, 5000)
+	insertAscending(b2, 5000)
+
+	h1, h2 := nodeHeights(b1), nodeHeights(b2)
+	if len(h1) != len(h2) {
+		t.Fatalf("Same seed should give the same number of nodes, but instead we got %d and %d", len(h1), len(h2))
+	}
+	tallest := 0
+	for i := range h1 {
+		if h1[i] != h2[i] {
+			t.Fatalf("Same seed should give the same heights, but at node %d we got %d and %d", i, h1[i], h2[i])
+		}
+		tallest = max(tallest, h1[i])
+	}
+	if tallest < 2 {
+		t.Fatalf("Some node should be taller than 1, but instead the tallest is %d", tallest)
+	}
+}
+
+func TestBowlCustomLevelGenerator(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(8), WithLevelGenerator(fixedLevelGenerator(1)))
+	insertAscending(b, 1000)
+	for i, h := range nodeHeights(b) {
+		if h != 1 {
+			t.Fatalf("Node %d should have height 1, but instead we got %d", i, h)
+		}
+	}
+
+	// out of range levels are kept inside [1, maxHeight]
+	b = NewBOWL[int, int](cmpTest, WithNodeSize(8), WithMaxHeight(4), WithLevelGenerator(fixedLevelGenerator(100)))
+	insertAscending(b, 1000)
+	for i, h := range nodeHeights(b) {
+		if h != 4 {
+			t.Fatalf("Node %d should have height 4, but instead we got %d", i, h)
+		}
+	}
+	checkBowlLinks(t, b)
+	if got := len(snapshotContent(b)); got != 1000 {
+		t.Fatalf("Bowl should hold 1000 data, but instead we got %d", got)
+	}
+}
+
+func TestBowlConcurrentInstances(t *testing.T) {
+	// run with -race, each Bowl should only touch its own level generator
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b := NewBOWL[int, int](cmpTest, WithNodeSize(4))
+			insertAscending(b, 2000)
+			if got := len(snapshotContent(b)); got != 2000 {
+				t.Errorf("Bowl should hold 2000 data, but instead we got %d", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
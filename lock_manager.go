@@ -0,0 +1,245 @@
+package bowl
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrDeadlock = errors.New("Waiting for the lock would deadlock, the transaction is aborted")
+
+// LockMode is the kind of a lock taken from a LockManager
+type LockMode int
+
+const (
+	// LOCK_SHARED is compatible with other LOCK_SHARED, used for reads
+	LOCK_SHARED LockMode = 0
+	// LOCK_EXCLUSIVE is compatible with nothing, used for writes
+	LOCK_EXCLUSIVE LockMode = 1
+)
+
+// rangeLock is a lock granted to owner, on all keys inside bounds
+type rangeLock[k comparable] struct {
+	owner  uint64
+	bounds Bounds[k]
+	mode   LockMode
+}
+
+// LockManager grants shared and exclusive locks on keys and on ranges of keys, ordered by its Comparator.
+// A single key is locked as the range from it to itself, so a range lock also covers the keys not there yet,
+// preventing phantoms.
+//
+// Locks are held by an owner, an id from NewOwner, until ReleaseAll. Locks of the same owner never conflict,
+// so it can lock again, or upgrade a shared lock to exclusive. A conflicting request waits, while the owners it waits for
+// are kept in a wait-for graph. If waiting would close a cycle, the request fails with ErrDeadlock instead,
+// so its owner should abort and release everything.
+//
+// For simplicity, granted locks are kept in a single list, checked on every request.
+// It fits a handful of concurrent transactions on contended keys, not a lock per every read of a busy Bowl
+type LockManager[k comparable] struct {
+	cmp Comparator[k]
+
+	mu      sync.Mutex
+	granted sync.Cond
+	locks   []rangeLock[k]
+	// waiting has the request each waiting owner waits on. The wait-for graph is derived from it
+	// together with locks on every check, so it is never stale
+	waiting map[uint64]rangeLock[k]
+
+	lastOwner atomic.Uint64
+}
+
+// NewLockManager creates an empty LockManager, ordering keys with cmp
+func NewLockManager[k comparable](cmp Comparator[k]) *LockManager[k] {
+	lm := &LockManager[k]{
+		cmp:     cmp,
+		locks:   make([]rangeLock[k], 0),
+		waiting: make(map[uint64]rangeLock[k]),
+	}
+	lm.granted.L = &lm.mu
+	return lm
+}
+
+// NewOwner returns a new owner id, never returned before by this LockManager
+func (lm *LockManager[k]) NewOwner() uint64 {
+	return lm.lastOwner.Add(1)
+}
+
+// LockKey locks key for owner, see LockRange
+func (lm *LockManager[k]) LockKey(owner uint64, key k, mode LockMode) error {
+	return lm.LockRange(owner, Bounds[k]{Lower: Inclusive(key), Upper: Inclusive(key)}, mode)
+}
+
+// LockRange locks every key inside bounds for owner, waiting until no other owner holds a conflicting lock.
+// Returns ErrDeadlock, without taking anything, if the owners it would wait for are (indirectly) waiting for owner
+func (lm *LockManager[k]) LockRange(owner uint64, bounds Bounds[k], mode LockMode) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	request := rangeLock[k]{owner: owner, bounds: bounds, mode: mode}
+	defer delete(lm.waiting, owner)
+	for {
+		blockers := lm.blockersOf(request)
+		if len(blockers) == 0 {
+			break
+		}
+		if lm.reaches(blockers, owner) {
+			return ErrDeadlock
+		}
+		lm.waiting[owner] = request
+		lm.granted.Wait()
+	}
+
+	lm.locks = append(lm.locks, request)
+	return nil
+}
+
+// ReleaseAll releases every lock of owner, waking up those waiting
+func (lm *LockManager[k]) ReleaseAll(owner uint64) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	kept := lm.locks[:0]
+	for _, l := range lm.locks {
+		if l.owner != owner {
+			kept = append(kept, l)
+		}
+	}
+	clear(lm.locks[len(kept):])
+	lm.locks = kept
+	lm.granted.Broadcast()
+}
+
+// blockersOf returns the other owners holding a lock conflicting with request
+//
+// Should only be called when mu is held
+func (lm *LockManager[k]) blockersOf(request rangeLock[k]) []uint64 {
+	var blockers []uint64
+	for _, l := range lm.locks {
+		if l.owner == request.owner || (l.mode == LOCK_SHARED && request.mode == LOCK_SHARED) {
+			continue
+		}
+		if l.bounds.overlaps(lm.cmp, request.bounds) {
+			blockers = append(blockers, l.owner)
+		}
+	}
+	return blockers
+}
+
+// reaches checks whether target can be reached from any of `from` in the wait-for graph
+//
+// Should only be called when mu is held
+func (lm *LockManager[k]) reaches(from []uint64, target uint64) bool {
+	visited := make(map[uint64]bool)
+	stack := append([]uint64(nil), from...)
+	for len(stack) > 0 {
+		owner := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if owner == target {
+			return true
+		}
+		if visited[owner] {
+			continue
+		}
+		visited[owner] = true
+		if request, ok := lm.waiting[owner]; ok {
+			stack = append(stack, lm.blockersOf(request)...)
+		}
+	}
+	return false
+}
+
+// LockingTxn is a read-write transaction using two-phase locking, started by Bowl.BeginLocking.
+// Reads take shared locks, scans on their whole bounds, and writes take exclusive locks,
+// all held until Commit or Rollback. Writes are kept in it until Commit, just like Txn,
+// but Commit never conflicts, as nothing read can be changed by another LockingTxn in the meantime.
+//
+// Plain writes to the Bowl do not take any lock, so they are not isolated from it.
+// When any method returns ErrDeadlock, the LockingTxn is already rolled back, and can be retried from BeginLocking.
+//
+// A LockingTxn is not goroutine-safe
+type LockingTxn[k comparable, v any] struct {
+	txn   *Txn[k, v]
+	lm    *LockManager[k]
+	owner uint64
+}
+
+// BeginLocking starts a new LockingTxn on this Bowl, taking its locks from lm.
+// lm should be shared by every LockingTxn of this Bowl, and order keys just like it
+func (b *Bowl[k, v]) BeginLocking(lm *LockManager[k]) *LockingTxn[k, v] {
+	return &LockingTxn[k, v]{
+		txn:   &Txn[k, v]{b: b},
+		lm:    lm,
+		owner: lm.NewOwner(),
+	}
+}
+
+// lock takes the lock on bounds, rolling back on ErrDeadlock
+func (t *LockingTxn[k, v]) lock(bounds Bounds[k], mode LockMode) error {
+	if t.txn.done {
+		return ErrTxnDone
+	}
+	if err := t.lm.LockRange(t.owner, bounds, mode); err != nil {
+		t.Rollback()
+		return err
+	}
+	return nil
+}
+
+// Get locks the given keys shared, in the given order, then returns their values, see Txn.Get
+func (t *LockingTxn[k, v]) Get(keys []k, notFoundDefaultValue v) ([]v, error) {
+	for _, key := range keys {
+		if err := t.lock(Bounds[k]{Lower: Inclusive(key), Upper: Inclusive(key)}, LOCK_SHARED); err != nil {
+			return nil, err
+		}
+	}
+	return t.txn.Get(keys, notFoundDefaultValue)
+}
+
+// ScanAll locks everything shared, then pass each data to fn, see Txn.ScanBoundsWhile
+func (t *LockingTxn[k, v]) ScanAll(fn func(Item[k, v])) error {
+	return t.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanRange locks fromKey <= key <= toKey shared, then pass each data inside to fn, see Txn.ScanBoundsWhile
+func (t *LockingTxn[k, v]) ScanRange(fromKey k, toKey k, fn func(Item[k, v])) error {
+	return t.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanBoundsWhile locks the whole `bounds` shared, then pass each data inside to fn,
+// and stops as soon as fn returns false, see Txn.ScanBoundsWhile
+func (t *LockingTxn[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	if err := t.lock(bounds, LOCK_SHARED); err != nil {
+		return err
+	}
+	return t.txn.ScanBoundsWhile(bounds, fn)
+}
+
+// Put locks key exclusive, then writes it on Commit, inserting or replacing it
+func (t *LockingTxn[k, v]) Put(key k, value v) error {
+	if err := t.lock(Bounds[k]{Lower: Inclusive(key), Upper: Inclusive(key)}, LOCK_EXCLUSIVE); err != nil {
+		return err
+	}
+	return t.txn.Put(key, value)
+}
+
+// Delete locks key exclusive, then deletes it on Commit, if it is there by then
+func (t *LockingTxn[k, v]) Delete(key k) error {
+	if err := t.lock(Bounds[k]{Lower: Inclusive(key), Upper: Inclusive(key)}, LOCK_EXCLUSIVE); err != nil {
+		return err
+	}
+	return t.txn.Delete(key)
+}
+
+// Commit applies all writes of this LockingTxn at once, then releases all its locks
+func (t *LockingTxn[k, v]) Commit() error {
+	defer t.lm.ReleaseAll(t.owner)
+	return t.txn.Commit()
+}
+
+// Rollback drops all writes of this LockingTxn, and releases all its locks.
+// Calling it after Commit, or more than once, is fine
+func (t *LockingTxn[k, v]) Rollback() {
+	t.txn.Rollback()
+	t.lm.ReleaseAll(t.owner)
+}
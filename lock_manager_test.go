@@ -0,0 +1,178 @@
+package bowl
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockWithin tries to take the lock in another goroutine,
+// returning whether it is granted within d, and a channel giving the final result
+func lockWithin(lm *LockManager[int], owner uint64, bounds Bounds[int], mode LockMode, d time.Duration) (bool, chan error) {
+	result := make(chan error, 1)
+	go func() {
+		result <- lm.LockRange(owner, bounds, mode)
+	}()
+	select {
+	case err := <-result:
+		result <- err
+		return true, result
+	case <-time.After(d):
+		return false, result
+	}
+}
+
+func TestLockManagerConflicts(t *testing.T) {
+	key := func(key int) Bounds[int] { return Bounds[int]{Lower: Inclusive(key), Upper: Inclusive(key)} }
+	cases := map[string]struct {
+		held      Bounds[int]
+		heldMode  LockMode
+		asked     Bounds[int]
+		askedMode LockMode
+		granted   bool
+	}{
+		"shared and shared":   {key(1), LOCK_SHARED, key(1), LOCK_SHARED, true},
+		"shared and excl":     {key(1), LOCK_SHARED, key(1), LOCK_EXCLUSIVE, false},
+		"excl and shared":     {key(1), LOCK_EXCLUSIVE, key(1), LOCK_SHARED, false},
+		"other keys":          {key(1), LOCK_EXCLUSIVE, key(2), LOCK_EXCLUSIVE, true},
+		"key inside range":    {Bounds[int]{Lower: Inclusive(1), Upper: Inclusive(10)}, LOCK_SHARED, key(5), LOCK_EXCLUSIVE, false},
+		"key at range end":    {Bounds[int]{Lower: Inclusive(1), Upper: Inclusive(10)}, LOCK_SHARED, key(10), LOCK_EXCLUSIVE, false},
+		"key at excluded end": {Bounds[int]{Lower: Inclusive(1), Upper: Exclusive(10)}, LOCK_SHARED, key(10), LOCK_EXCLUSIVE, true},
+		"unbounded range":     {Bounds[int]{Lower: Exclusive(1)}, LOCK_SHARED, key(1000), LOCK_EXCLUSIVE, false},
+		"before unbounded":    {Bounds[int]{Lower: Exclusive(1)}, LOCK_SHARED, key(1), LOCK_EXCLUSIVE, true},
+		"overlapping ranges": {Bounds[int]{Upper: Inclusive(5)}, LOCK_EXCLUSIVE,
+			Bounds[int]{Lower: Inclusive(5), Upper: Inclusive(8)}, LOCK_SHARED, false},
+	}
+
+	for name, c := range cases {
+		lm := NewLockManager[int](cmpTest)
+		holder, asker := lm.NewOwner(), lm.NewOwner()
+		if err := lm.LockRange(holder, c.held, c.heldMode); err != nil {
+			t.Fatalf("%s: First lock should be granted, but instead we got %v", name, err)
+		}
+		granted, result := lockWithin(lm, asker, c.asked, c.askedMode, 20*time.Millisecond)
+		if granted != c.granted {
+			t.Fatalf("%s: Second lock granted should be %v, but instead we got %v", name, c.granted, granted)
+		}
+
+		lm.ReleaseAll(holder)
+		if err := <-result; err != nil {
+			t.Fatalf("%s: Second lock should be granted after release, but instead we got %v", name, err)
+		}
+		// the same owner never conflicts with itself, e.g. upgrading
+		if err := lm.LockRange(asker, c.asked, LOCK_EXCLUSIVE); err != nil {
+			t.Fatalf("%s: Owner should be able to upgrade its own lock, but instead we got %v", name, err)
+		}
+	}
+}
+
+func TestLockManagerDeadlock(t *testing.T) {
+	lm := NewLockManager[int](cmpTest)
+	a, b, c := lm.NewOwner(), lm.NewOwner(), lm.NewOwner()
+	lm.LockKey(a, 1, LOCK_EXCLUSIVE)
+	lm.LockKey(b, 2, LOCK_EXCLUSIVE)
+	lm.LockKey(c, 3, LOCK_SHARED)
+
+	// a -> b -> c, not a cycle yet
+	grantedA, resultA := lockWithin(lm, a, Bounds[int]{Lower: Inclusive(2), Upper: Inclusive(2)}, LOCK_SHARED, 10*time.Millisecond)
+	grantedB, resultB := lockWithin(lm, b, Bounds[int]{Lower: Inclusive(3), Upper: Inclusive(3)}, LOCK_EXCLUSIVE, 10*time.Millisecond)
+	if grantedA || grantedB {
+		t.Fatalf("Locks held by others should not be granted, but instead we got %v %v", grantedA, grantedB)
+	}
+
+	// c -> a closes the cycle
+	if err := lm.LockKey(c, 1, LOCK_SHARED); err != ErrDeadlock {
+		t.Fatalf("Lock closing a cycle should fail with ErrDeadlock, but instead we got %v", err)
+	}
+	lm.ReleaseAll(c)
+	if err := <-resultB; err != nil {
+		t.Fatalf("b should get its lock once c aborts, but instead we got %v", err)
+	}
+	lm.ReleaseAll(b)
+	if err := <-resultA; err != nil {
+		t.Fatalf("a should get its lock once b is done, but instead we got %v", err)
+	}
+}
+
+func TestLockingTxnPreventsPhantom(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	b.Insert([]Item[int, int]{{Key: 10, Value: 10}, {Key: 20, Value: 20}})
+	lm := NewLockManager[int](cmpTest)
+
+	reader := b.BeginLocking(lm)
+	count := 0
+	reader.ScanRange(10, 20, func(Item[int, int]) { count++ })
+
+	writer := b.BeginLocking(lm)
+	done := make(chan error, 1)
+	go func() {
+		if err := writer.Put(15, 15); err != nil {
+			done <- err
+			return
+		}
+		done <- writer.Commit()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	again := 0
+	reader.ScanRange(10, 20, func(Item[int, int]) { again++ })
+	if count != 2 || again != 2 {
+		t.Fatalf("Range scanned should not change until commit, but instead we got %d then %d", count, again)
+	}
+	if err := reader.Commit(); err != nil {
+		t.Fatalf("Reader Commit should succeed, but instead we got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Writer should go on after the reader commits, but instead we got %v", err)
+	}
+	if values := b.Get([]int{15}, 0); values[0] != 15 {
+		t.Fatalf("Writer Commit should be applied, but instead we got %d", values[0])
+	}
+}
+
+func TestLockingTxnConcurrentTransfers(t *testing.T) {
+	// run with -race, both keys are read shared then written exclusive, so upgrades deadlock often,
+	// the aborted ones are retried, and every transfer should be kept
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode))
+		b.Insert([]Item[int, int]{{Key: 0, Value: 0}, {Key: 1, Value: 0}})
+		lm := NewLockManager[int](cmpTest)
+
+		const writers, transfers = 8, 30
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < transfers; i++ {
+					for {
+						txn := b.BeginLocking(lm)
+						values, err := txn.Get([]int{0, 1}, 0)
+						if err == nil {
+							err = txn.Put(0, values[0]+1)
+						}
+						if err == nil {
+							err = txn.Put(1, values[1]-1)
+						}
+						if err == nil {
+							err = txn.Commit()
+						}
+						if err == nil {
+							break
+						}
+						if err != ErrDeadlock {
+							t.Errorf("%s: Only ErrDeadlock is expected, but instead we got %v", name, err)
+							return
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		values := b.Get([]int{0, 1}, 0)
+		if values[0] != writers*transfers || values[1] != -writers*transfers {
+			t.Fatalf("%s: Every transfer should be kept, but instead we got %v", name, values)
+		}
+	}
+}
@@ -0,0 +1,649 @@
+package bowl
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// LockFreeBowl is an unrolled skip list whose reads never take any lock,
+// for read-heavy usage. Writers are still serialized by a mutex between themselves,
+// but never block the readers.
+//
+// Each node's data and its next node at height 0 are kept together in an immutable content,
+// replaced as a whole (copy-on-write) with a single atomic store, once per batch.
+// So readers get both with a single load, always belonging together, and never wait nor retry.
+// Links at the upper heights are atomic pointers on their own, only used to skip ahead.
+// This takes the place of per-node version counters: with those, a reader has to wait
+// while a writer is in the middle of changing a node, and retry when the version moved,
+// while a single pointer swap never shows anything half-written.
+//
+// A split links the new node before shrinking the old one,
+// and an emptied node is marked removed and unlinked, but its own links are kept,
+// so readers already on it can still move on. Removed nodes are reclaimed by the garbage collector
+// once no reader holds them anymore.
+//
+// Each single item is linearizable, but a batch is NOT isolated, readers can see part of it.
+// A scan sees each node as of the time it gets there, not a point-in-time view
+type LockFreeBowl[k comparable, v any] struct {
+	// writeMu serializes the writers, readers never touch it
+	writeMu sync.Mutex
+	head    *lockFreeNode[k, v]
+	cmp     Comparator[k]
+
+	// preds[h] is the last node at height h covering or before the key being written,
+	// preds[0] being the node that should has it. Only used by the writer holding writeMu
+	preds []*lockFreeNode[k, v]
+	// work is the writer's private copy of preds[0]'s data, nil until it is changed
+	work []Item[k, v]
+
+	strictValidation bool
+	nodeSize         int
+	maxHeight        int
+	splitRatio       float64
+	levelGenerator   LevelGenerator
+}
+
+// lockFreeContent is what a lockFreeNode publishes at once. Never changed once stored
+type lockFreeContent[k comparable, v any] struct {
+	data []Item[k, v]
+	next *lockFreeNode[k, v]
+}
+
+// lockFreeNode is the Node of LockFreeBowl
+type lockFreeNode[k comparable, v any] struct {
+	state   atomic.Int32
+	content atomic.Pointer[lockFreeContent[k, v]]
+	// nextNodes has the links at height 1 and above, nextNodes[0] is unused, as it is in content
+	nextNodes []atomic.Pointer[lockFreeNode[k, v]]
+	cmp       Comparator[k]
+
+	// lowKey is the smallest key this node covers, only when hasLowKey. Never changes
+	lowKey    k
+	hasLowKey bool
+}
+
+func newLockFreeNode[k comparable, v any](
+	h int, data []Item[k, v], cmp Comparator[k]) *lockFreeNode[k, v] {
+	n := &lockFreeNode[k, v]{
+		nextNodes: make([]atomic.Pointer[lockFreeNode[k, v]], h),
+		cmp:       cmp,
+	}
+	n.content.Store(&lockFreeContent[k, v]{data: data})
+	return n
+}
+
+// NewLockFreeBOWL creates our new empty LockFreeBowl, with given Comparator and options.
+// WithLockingMode does not apply here
+func NewLockFreeBOWL[k comparable, v any](cmp Comparator[k], opts ...Option) *LockFreeBowl[k, v] {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	b := &LockFreeBowl[k, v]{
+		head:             newLockFreeNode[k, v](o.maxHeight, nil, cmp),
+		cmp:              cmp,
+		preds:            make([]*lockFreeNode[k, v], o.maxHeight),
+		strictValidation: o.strictValidation,
+		nodeSize:         o.nodeSize,
+		maxHeight:        o.maxHeight,
+		splitRatio:       o.splitRatio,
+		levelGenerator:   o.getLevelGenerator(),
+	}
+	return b
+}
+
+func (n *lockFreeNode[k, v]) next(h int) *lockFreeNode[k, v] {
+	if h == 0 {
+		return n.content.Load().next
+	}
+	return n.nextNodes[h].Load()
+}
+
+// checkKeyStrictlyLessThanLowKey checks whether key is less than the low key of this node.
+// The first node has no low key, so it never is
+func (n *lockFreeNode[k, v]) checkKeyStrictlyLessThanLowKey(key k) bool {
+	return n.hasLowKey && n.cmp(key, n.lowKey) == -1
+}
+
+// load returns the data and the next node at height 0, both from the same content
+func (n *lockFreeNode[k, v]) load() ([]Item[k, v], *lockFreeNode[k, v]) {
+	c := n.content.Load()
+	return c.data, c.next
+}
+
+// store publishes data together with next as the new content
+//
+// Should only be called when writeMu is held
+func (n *lockFreeNode[k, v]) store(data []Item[k, v], next *lockFreeNode[k, v]) {
+	n.content.Store(&lockFreeContent[k, v]{data: data, next: next})
+}
+
+// search returns the position of the first data at least `key`, and whether it is equal
+func searchItems[k comparable, v any](data []Item[k, v], key k, cmp Comparator[k]) (int, bool) {
+	return slices.BinarySearchFunc(data, key, func(ih Item[k, v], key k) int {
+		return cmp(ih.Key, key)
+	})
+}
+
+// ------------------------------------------------------------------------
+// Reads
+// ------------------------------------------------------------------------
+
+// locate returns the node that should has key, starting from `node`, and its data.
+// The data is from the same content as the next node found not covering key
+func (b *LockFreeBowl[k, v]) locate(node *lockFreeNode[k, v], key k) (*lockFreeNode[k, v], []Item[k, v]) {
+	for h := len(node.nextNodes) - 1; h > 0; h-- {
+		for {
+			next := node.next(h)
+			if next == nil || next.checkKeyStrictlyLessThanLowKey(key) {
+				break
+			}
+			node = next
+		}
+	}
+	for {
+		data, next := node.load()
+		if next == nil || next.checkKeyStrictlyLessThanLowKey(key) {
+			return node, data
+		}
+		node = next
+	}
+}
+
+// Get returns all values for the given keys
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed.
+// With WithStrictValidation, an invalid batch gets notFoundDefaultValue for every key,
+// use TryGet to get the validation error as well
+func (b *LockFreeBowl[k, v]) Get(keys []k, notFoundDefaultValue v) []v {
+	result, err := b.TryGet(keys, notFoundDefaultValue)
+	if err != nil {
+		return fillValues(make([]v, len(keys)), notFoundDefaultValue)
+	}
+	return result
+}
+
+// TryGet returns all values for the given keys,
+// or the validation error when WithStrictValidation is used and the batch is invalid
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *LockFreeBowl[k, v]) TryGet(keys []k, notFoundDefaultValue v) ([]v, error) {
+	if err := b.validateKeys(keys); err != nil {
+		return nil, err
+	}
+	return b.get(keys, notFoundDefaultValue), nil
+}
+
+// get returns all values for the given keys, without any validation
+func (b *LockFreeBowl[k, v]) get(keys []k, notFoundDefaultValue v) []v {
+	result := make([]v, len(keys))
+	node := b.head
+	var data []Item[k, v]
+	for i, key := range keys {
+		node, data = b.locate(node, key)
+		result[i] = notFoundDefaultValue
+		if pos, found := searchItems(data, key, b.cmp); found {
+			result[i] = data[pos].Value
+		}
+	}
+	return result
+}
+
+// ScanAll pass each data to fn
+func (b *LockFreeBowl[k, v]) ScanAll(fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanAllWhile pass each data to fn, and stops as soon as fn returns false
+func (b *LockFreeBowl[k, v]) ScanAllWhile(fn func(Item[k, v]) bool) {
+	b.ScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ScanGreaterThanEqual pass each data greater than `key` to fn
+func (b *LockFreeBowl[k, v]) ScanGreaterThanEqual(key k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanStrictlyLessThan pass each data until `key` to fn
+func (b *LockFreeBowl[k, v]) ScanStrictlyLessThan(key k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanRange pass each data between fromKey <= data <= toKey
+func (b *LockFreeBowl[k, v]) ScanRange(fromKey k, toKey k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanBoundsWhile pass each data inside `bounds` to fn,
+// and stops as soon as fn returns false
+func (b *LockFreeBowl[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	node := b.head
+	if bounds.Lower.Type != UNBOUNDED {
+		node, _ = b.locate(b.head, bounds.Lower.Key)
+	}
+
+	for node != nil {
+		data, next := node.load()
+		for _, ih := range data {
+			if !bounds.satisfiesLower(b.cmp, ih.Key) {
+				continue
+			}
+			if !bounds.satisfiesUpper(b.cmp, ih.Key) || !fn(ih) {
+				return
+			}
+		}
+		node = next
+	}
+}
+
+// ReverseScanAll pass each data to fn, in descending order
+func (b *LockFreeBowl[k, v]) ReverseScanAll(fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ReverseScanAllWhile pass each data to fn, in descending order,
+// and stops as soon as fn returns false
+func (b *LockFreeBowl[k, v]) ReverseScanAllWhile(fn func(Item[k, v]) bool) {
+	b.ReverseScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ReverseScanGreaterThanEqual pass each data greater than or equal `key` to fn, in descending order
+func (b *LockFreeBowl[k, v]) ReverseScanGreaterThanEqual(key k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanStrictlyLessThan pass each data strictly less than `key` to fn, in descending order
+func (b *LockFreeBowl[k, v]) ReverseScanStrictlyLessThan(key k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanRange pass each data between fromKey <= data <= toKey, in descending order
+func (b *LockFreeBowl[k, v]) ReverseScanRange(fromKey k, toKey k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ReverseScanBoundsWhile pass each data inside `bounds` to fn, in descending order,
+// and stops as soon as fn returns false
+//
+// There is no back-link, each step back searches for the node right before the current one
+func (b *LockFreeBowl[k, v]) ReverseScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	var node *lockFreeNode[k, v]
+	var data []Item[k, v]
+	if bounds.Upper.Type == UNBOUNDED {
+		node, data = b.locateLast()
+	} else {
+		node, data = b.locate(b.head, bounds.Upper.Key)
+	}
+
+	var lowKey k
+	hasLowKey := false
+	for {
+		for i := len(data) - 1; i >= 0; i-- {
+			// a node removed meanwhile makes the one before it cover its range,
+			// skip what comes after what we already passed
+			if !bounds.satisfiesUpper(b.cmp, data[i].Key) || hasLowKey && b.cmp(data[i].Key, lowKey) != -1 {
+				continue
+			}
+			if !bounds.satisfiesLower(b.cmp, data[i].Key) || !fn(data[i]) {
+				return
+			}
+		}
+		if !node.hasLowKey { // the first node, or head
+			return
+		}
+		lowKey, hasLowKey = node.lowKey, true
+		node, data = b.locateBefore(lowKey)
+	}
+}
+
+// locateLast returns the last node, and its data
+func (b *LockFreeBowl[k, v]) locateLast() (*lockFreeNode[k, v], []Item[k, v]) {
+	node := b.head
+	for h := b.maxHeight - 1; h > 0; h-- {
+		for next := node.next(h); next != nil; next = node.next(h) {
+			node = next
+		}
+	}
+	for {
+		data, next := node.load()
+		if next == nil {
+			return node, data
+		}
+		node = next
+	}
+}
+
+// locateBefore returns the node covering the keys right before `lowKey`, and its data
+func (b *LockFreeBowl[k, v]) locateBefore(lowKey k) (*lockFreeNode[k, v], []Item[k, v]) {
+	before := func(n *lockFreeNode[k, v]) bool {
+		return !n.hasLowKey || b.cmp(n.lowKey, lowKey) == -1
+	}
+	node := b.head
+	for h := b.maxHeight - 1; h > 0; h-- {
+		for next := node.next(h); next != nil && before(next); next = node.next(h) {
+			node = next
+		}
+	}
+	for {
+		data, next := node.load()
+		if next == nil || !before(next) {
+			return node, data
+		}
+		node = next
+	}
+}
+
+// ------------------------------------------------------------------------
+// Writes
+// ------------------------------------------------------------------------
+
+// Insert inserts all items, failing those whose key already exists
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *LockFreeBowl[k, v]) Insert(ihs []Item[k, v]) []error {
+	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err)
+	}
+	if len(ihs) == 0 {
+		return errs
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	b.resetPreds()
+
+	for i, ih := range ihs {
+		node := b.seekForWrite(ih.Key)
+		if _, found := searchItems(b.dataOf(node), ih.Key, b.cmp); found {
+			errs[i] = ErrKeyAlreadyExist
+			continue
+		}
+		b.insertIntoWork(node, ih)
+	}
+	b.publish()
+	return errs
+}
+
+// Upsert inserts each item, or replaces the value when the key already exists,
+// in a single pass. The result tells which one happened for each item
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *LockFreeBowl[k, v]) Upsert(ihs []Item[k, v]) []UpsertResult {
+	results := make([]UpsertResult, len(ihs))
+	if len(ihs) == 0 {
+		return results
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	b.resetPreds()
+
+	for i, ih := range ihs {
+		node := b.seekForWrite(ih.Key)
+		if pos, found := searchItems(b.dataOf(node), ih.Key, b.cmp); found {
+			b.workOf(node)[pos].Value = ih.Value
+			results[i] = UPSERT_REPLACED
+			continue
+		}
+		b.insertIntoWork(node, ih)
+		results[i] = UPSERT_CREATED
+	}
+	b.publish()
+	return results
+}
+
+// Update updates ih[i].Value when mathing ih[i].Key found
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *LockFreeBowl[k, v]) Update(ihs []Item[k, v]) []error {
+	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err)
+	}
+	if len(ihs) == 0 {
+		return errs
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	b.resetPreds()
+
+	for i, ih := range ihs {
+		node := b.seekForWrite(ih.Key)
+		data := b.dataOf(node)
+		if len(data) == 0 {
+			errs[i] = ErrNodeIsEmpty
+			continue
+		}
+		pos, found := searchItems(data, ih.Key, b.cmp)
+		if !found {
+			errs[i] = ErrDataNotFound
+			continue
+		}
+		b.workOf(node)[pos].Value = ih.Value
+	}
+	b.publish()
+	return errs
+}
+
+// Delete removes all matching keys
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *LockFreeBowl[k, v]) Delete(keys []k) []error {
+	errs := make([]error, len(keys))
+	if err := b.validateKeys(keys); err != nil {
+		return fillErrors(errs, err)
+	}
+	if len(keys) == 0 {
+		return errs
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	b.resetPreds()
+
+	for i, key := range keys {
+		node := b.seekForWrite(key)
+		data := b.dataOf(node)
+		if len(data) == 0 {
+			errs[i] = ErrNodeIsEmpty
+			continue
+		}
+		pos, found := searchItems(data, key, b.cmp)
+		if !found {
+			errs[i] = ErrDataNotFound
+			continue
+		}
+		b.work = slices.Delete(b.workOf(node), pos, pos+1)
+	}
+	b.publish()
+	return errs
+}
+
+func (b *LockFreeBowl[k, v]) resetPreds() {
+	for h := range b.preds {
+		b.preds[h] = b.head
+	}
+	b.work = nil
+}
+
+// seekForWrite returns the node that should has key, and moves preds there.
+// The current node's changes are published first, if it has to move
+//
+// Should only be called when writeMu is held
+func (b *LockFreeBowl[k, v]) seekForWrite(key k) *lockFreeNode[k, v] {
+	current := b.preds[0]
+	if current != b.head {
+		next := current.next(0)
+		if next == nil || next.checkKeyStrictlyLessThanLowKey(key) {
+			return current
+		}
+		b.publish()
+	}
+
+	node := b.head
+	for h := b.maxHeight - 1; h >= 0; h-- {
+		for {
+			next := node.next(h)
+			if next == nil || next.checkKeyStrictlyLessThanLowKey(key) {
+				break
+			}
+			node = next
+		}
+		b.preds[h] = node
+	}
+	if node != b.head {
+		return node
+	}
+
+	// meaning this Bowl is empty, create the first node
+	first := newLockFreeNode[k, v](b.generateLevel(), make([]Item[k, v], 0), b.cmp)
+	for h := 1; h < len(first.nextNodes); h++ {
+		b.head.nextNodes[h].Store(first)
+	}
+	b.head.store(nil, first)
+	for h := 0; h < len(first.nextNodes); h++ {
+		b.preds[h] = first
+	}
+	return first
+}
+
+// dataOf returns the data of `node` as the writer sees it
+//
+// Should only be called when writeMu is held, with `node` being preds[0]
+func (b *LockFreeBowl[k, v]) dataOf(node *lockFreeNode[k, v]) []Item[k, v] {
+	if b.work != nil {
+		return b.work
+	}
+	data, _ := node.load()
+	return data
+}
+
+// workOf returns the writer's private copy of `node`'s data, copying it first if needed
+//
+// Should only be called when writeMu is held, with `node` being preds[0]
+func (b *LockFreeBowl[k, v]) workOf(node *lockFreeNode[k, v]) []Item[k, v] {
+	if b.work == nil {
+		data, _ := node.load()
+		b.work = make([]Item[k, v], len(data), b.nodeSize)
+		copy(b.work, data)
+	}
+	return b.work
+}
+
+// insertIntoWork inserts ih, which should not exist yet, splitting `node` if it is full
+//
+// Should only be called when writeMu is held, with `node` being preds[0]
+func (b *LockFreeBowl[k, v]) insertIntoWork(node *lockFreeNode[k, v], ih Item[k, v]) {
+	if len(b.dataOf(node)) >= b.nodeSize {
+		node = b.splitForKey(node, ih.Key)
+	}
+	work := b.workOf(node)
+	pos, _ := searchItems(work, ih.Key, b.cmp)
+	b.work = slices.Insert(work, pos, ih)
+}
+
+// splitForKey moves the upper part of `node` into a new node right after it,
+// and returns whichever of them `key` should go into. Both are published.
+//
+// The new node is linked at the upper heights first, then `node` is shrunk and linked to it
+// at height 0 in a single store, so readers see the moved data exactly once,
+// either still in `node` or already in the new node
+//
+// Should only be called when writeMu is held, with `node` being preds[0]
+func (b *LockFreeBowl[k, v]) splitForKey(node *lockFreeNode[k, v], key k) *lockFreeNode[k, v] {
+	data := b.dataOf(node)
+	pos := splitPosition(len(data), b.splitRatio)
+	lower := slices.Clone(data[:pos])
+	newNode := newLockFreeNode[k, v](b.generateLevel(), nil, b.cmp)
+	newNode.store(slices.Clone(data[pos:]), node.next(0))
+	newNode.lowKey = data[pos].Key
+	newNode.hasLowKey = true
+
+	for h := 1; h < len(newNode.nextNodes); h++ {
+		newNode.nextNodes[h].Store(b.preds[h].next(h))
+		b.preds[h].nextNodes[h].Store(newNode)
+	}
+	node.store(lower, newNode)
+	b.work = nil
+
+	if newNode.checkKeyStrictlyLessThanLowKey(key) {
+		return node
+	}
+	for h := 0; h < len(newNode.nextNodes); h++ {
+		b.preds[h] = newNode
+	}
+	return newNode
+}
+
+// publish makes the writer's changes on preds[0] visible to the readers.
+// A node emptied by them is removed, unless it is the first one
+//
+// Should only be called when writeMu is held
+func (b *LockFreeBowl[k, v]) publish() {
+	if b.work == nil {
+		return
+	}
+	node, data := b.preds[0], b.work
+	b.work = nil
+	node.store(data, node.next(0))
+
+	if len(data) == 0 && node.hasLowKey {
+		b.remove(node)
+	}
+}
+
+// remove marks `node` removed, and unlinks it at every height.
+// Its own links are kept, so readers already on it can still move on.
+// preds are no longer valid afterwards
+//
+// Should only be called when writeMu is held
+func (b *LockFreeBowl[k, v]) remove(node *lockFreeNode[k, v]) {
+	if !node.state.CompareAndSwap(int32(ACTIVE), int32(MARKED_REMOVED)) {
+		panic(fmt.Sprintf("Should be no node removed twice, means something is broken: %v", node.lowKey))
+	}
+	before := func(n *lockFreeNode[k, v]) bool {
+		return n != node && (!n.hasLowKey || b.cmp(n.lowKey, node.lowKey) == -1)
+	}
+	prev := b.head
+	for h := b.maxHeight - 1; h >= 0; h-- {
+		for next := prev.next(h); next != nil && before(next); next = prev.next(h) {
+			prev = next
+		}
+		if h >= len(node.nextNodes) {
+			continue
+		}
+		if h == 0 {
+			prevData, _ := prev.load()
+			prev.store(prevData, node.next(0))
+		} else {
+			prev.nextNodes[h].Store(node.next(h))
+		}
+	}
+	b.resetPreds()
+}
+
+// generateLevel returns a random height for a new node, kept between 1 and maxHeight
+func (b *LockFreeBowl[k, v]) generateLevel() int {
+	return min(max(b.levelGenerator.Level(b.maxHeight), 1), b.maxHeight)
+}
+
+// validateKeys returns the validation error of keys, if strict validation is enabled
+func (b *LockFreeBowl[k, v]) validateKeys(keys []k) error {
+	if !b.strictValidation {
+		return nil
+	}
+	return validateSortedBatch(len(keys), func(i int) k { return keys[i] }, b.cmp)
+}
+
+// validateItems returns the validation error of ihs, if strict validation is enabled
+func (b *LockFreeBowl[k, v]) validateItems(ihs []Item[k, v]) error {
+	if !b.strictValidation {
+		return nil
+	}
+	return validateSortedBatch(len(ihs), func(i int) k { return ihs[i].Key }, b.cmp)
+}
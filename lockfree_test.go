@@ -0,0 +1,166 @@
+package bowl
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestLockFreeBowl(t *testing.T) {
+	b := NewLockFreeBOWL[int, int](cmpTest, WithNodeSize(8), WithSeed(42))
+	checkAgainstModel(t, "lock-free", b)
+	checkLockFreeBowlLinks(t, b)
+}
+
+func TestLockFreeBowlStrictValidation(t *testing.T) {
+	b := NewLockFreeBOWL[int, int](cmpTest, WithStrictValidation())
+	for _, err := range b.Insert([]Item[int, int]{{Key: 2}, {Key: 1}}) {
+		if err != (ErrUnsortedBatch{Index: 1}) {
+			t.Fatalf("Unsorted batch should fail, but instead we got %v", err)
+		}
+	}
+	if _, err := b.TryGet([]int{1, 1}, 0); err != (ErrDuplicateKeyInBatch{Index: 1}) {
+		t.Fatalf("Duplicate keys should fail, but instead we got %v", err)
+	}
+}
+
+func TestLockFreeBowlConcurrentReaders(t *testing.T) {
+	// run with -race, readers should always see ascending data and every key never deleted
+	b := NewLockFreeBOWL[int, int](cmpTest, WithNodeSize(16))
+	const total = 20000
+	stable := make([]Item[int, int], 0, total/2)
+	for i := 0; i < total; i += 2 {
+		stable = append(stable, Item[int, int]{Key: i, Value: i})
+	}
+	b.Insert(stable)
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				prev, evens := -1, 0
+				b.ScanAll(func(ih Item[int, int]) {
+					if ih.Key <= prev {
+						t.Errorf("Scan should be ascending, but %d came after %d", ih.Key, prev)
+					}
+					if ih.Key%2 == 0 {
+						evens++
+					}
+					prev = ih.Key
+				})
+				if evens != total/2 {
+					t.Errorf("Scan should see all %d stable data, but instead we got %d", total/2, evens)
+				}
+				next := math.MaxInt
+				b.ReverseScanAll(func(ih Item[int, int]) {
+					if ih.Key >= next {
+						t.Errorf("Reverse scan should be descending, but %d came after %d", ih.Key, next)
+					}
+					next = ih.Key
+				})
+				for i, value := range b.Get([]int{0, total / 2, total - 2}, -1) {
+					if value == -1 {
+						t.Errorf("Get of stable key at %d should be found, but it is not", i)
+					}
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	for w := 0; w < 2; w++ {
+		writers.Add(1)
+		go func(w int) {
+			defer writers.Done()
+			// odd keys come and go, splitting and emptying nodes all the time
+			for round := 0; round < 20; round++ {
+				ihs := make([]Item[int, int], 0, total/4)
+				keys := make([]int, 0, total/4)
+				for i := 1 + w*2; i < total; i += 4 {
+					ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+					keys = append(keys, i)
+				}
+				b.Upsert(ihs)
+				b.Delete(keys)
+			}
+		}(w)
+	}
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	content := make([]Item[int, int], 0, total/2)
+	b.ScanAll(func(ih Item[int, int]) {
+		content = append(content, ih)
+	})
+	checkSameContent(t, "after concurrent writes", stable, content)
+	checkLockFreeBowlLinks(t, b)
+}
+
+// checkLockFreeBowlLinks checks every height only links nodes with ascending low keys,
+// all of them reachable at height 0, and no removed node remains
+func checkLockFreeBowlLinks(t *testing.T, b *LockFreeBowl[int, int]) {
+	reachable := make(map[*lockFreeNode[int, int]]bool)
+	for node := b.head.next(0); node != nil; node = node.next(0) {
+		reachable[node] = true
+		if node.state.Load() != int32(ACTIVE) {
+			t.Fatalf("Removed node with low key %d should be unlinked, but it is not", node.lowKey)
+		}
+	}
+	for h := 0; h < b.maxHeight; h++ {
+		prev := b.head
+		for node := b.head.next(h); node != nil; node = node.next(h) {
+			if !reachable[node] {
+				t.Fatalf("Node at height %d should be reachable at height 0, but it is not", h)
+			}
+			if prev != b.head && (!node.hasLowKey || node.lowKey <= prev.lowKey) {
+				t.Fatalf("Low keys at height %d should be ascending, but %d came after %d", h, node.lowKey, prev.lowKey)
+			}
+			prev = node
+		}
+	}
+}
+
+// BenchmarkLockFreeBowlReadParallel has every goroutine reading while one keeps writing
+func BenchmarkLockFreeBowlReadParallel(b *testing.B) {
+	bowl := NewLockFreeBOWL[int, int](cmpTest)
+	data := make([]Item[int, int], 100000)
+	for i := range data {
+		data[i] = Item[int, int]{Key: i * 2, Value: i}
+	}
+	bowl.Insert(data)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 1; ; i += 2 {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			bowl.Upsert([]Item[int, int]{{Key: i % 200000, Value: i}})
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		keys := make([]int, 1024)
+		start := 0
+		for pb.Next() {
+			for j := range keys {
+				keys[j] = (start + j) * 2
+			}
+			start = (start + len(keys)) % (len(data) - len(keys))
+			bowl.Get(keys, 0)
+		}
+	})
+}
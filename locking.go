@@ -0,0 +1,342 @@
+package bowl
+
+import (
+	"context"
+	"fmt"
+)
+
+// LockingMode chooses how a Bowl synchronizes its operations, see WithLockingMode
+type LockingMode int
+
+const (
+	// LOCKING_GLOBAL makes every write hold the whole Bowl, and every read share it.
+	// It has `STRICT SERIALIZABLE` isolation level, as every batch is applied as a single step
+	LOCKING_GLOBAL LockingMode = 0
+
+	// LOCKING_PER_NODE makes Get, Insert, Update, Delete, Upsert, and the scans
+	// only share the Bowl, and latch the node they are at, hand-over-hand along height 0.
+	// A split only latches the node being split and the one after it,
+	// so batches touching disjoint key ranges run in parallel.
+	//
+	// Each single item is linearizable, but a batch is NOT isolated:
+	// others can see the items it already did before it finishes, and write in between.
+	// Likewise, a scan sees each node as of the time it gets there, not a point-in-time view.
+	//
+	// Apply, CompareAndSwap, Write, the atomic batches, and Cursor still hold the whole Bowl,
+	// so they keep `STRICT SERIALIZABLE` against everything else.
+	//
+	// Nodes emptied by deletes are never unlinked in this mode, they are made alive by later inserts
+	LOCKING_PER_NODE LockingMode = 1
+)
+
+// readLock takes what the reads without node latches need. That is the read lock with LOCKING_GLOBAL,
+// but the whole Bowl with LOCKING_PER_NODE, as the writers there only share it
+func (b *Bowl[k, v]) readLock() {
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.Lock()
+		return
+	}
+	b.RLock()
+}
+
+// readUnlock releases what readLock took
+func (b *Bowl[k, v]) readUnlock() {
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.Unlock()
+		return
+	}
+	b.RUnlock()
+}
+
+// writeLock takes what Insert, Update, Delete, and Upsert need. That is the whole Bowl with LOCKING_GLOBAL,
+// but only the read lock with LOCKING_PER_NODE, as they latch the nodes themselves
+func (b *Bowl[k, v]) writeLock() {
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.RLock()
+		return
+	}
+	b.Lock()
+}
+
+// writeUnlock releases what writeLock took
+func (b *Bowl[k, v]) writeUnlock() {
+	if b.lockingMode == LOCKING_PER_NODE {
+		b.RUnlock()
+		return
+	}
+	b.Unlock()
+}
+
+// keyAtOrAfterLowKey returns whether `next` covers key, or anything before it
+func keyAtOrAfterLowKey[k comparable, v any](key k) func(next *Node[k, v]) bool {
+	return func(next *Node[k, v]) bool {
+		return !next.checkKeyStrictlyLessThanLowKey(key)
+	}
+}
+
+// lockNodeWhere returns the last node where `goesAfter` is true for it and every node before it, latched.
+// Returns nil if this Bowl is empty, unless `create`, where the first node is created and added to pending.
+//
+// The links above height 0 are only used as a hint, as in this mode a node is never unlinked,
+// and a low key never changes. The correct node is found by moving right at height 0, hand-over-hand.
+//
+// Should only be called when the read lock is held, with LOCKING_PER_NODE
+func (b *Bowl[k, v]) lockNodeWhere(
+	goesAfter func(next *Node[k, v]) bool, create bool, pending *[]*Node[k, v]) *Node[k, v] {
+	b.towerMu.RLock()
+	node := b.head
+	for h := b.maxHeight - 1; h > 0; h-- {
+		for {
+			next, _ := node.GetNextNodeAt(h)
+			if next == nil || !goesAfter(next) {
+				break
+			}
+			node = next
+		}
+	}
+	b.towerMu.RUnlock()
+
+	node.latch.Lock()
+	if node == b.head {
+		// the first node covers everything before it, whatever its low key is
+		first, _ := b.head.GetNextNodeAt(0)
+		if first == nil {
+			if !create {
+				b.head.latch.Unlock()
+				return nil
+			}
+			first = b.createNode(b.generateLevel())
+			first.ConnectPrevNode(b.head)
+			b.head.ConnectNode(0, first)
+			*pending = append(*pending, first)
+		}
+		first.latch.Lock()
+		b.head.latch.Unlock()
+		node = first
+	}
+	return b.moveRightLatchedWhere(node, goesAfter)
+}
+
+// moveRightLatchedWhere moves from the latched `node` along height 0, hand-over-hand,
+// as long as `goesAfter` is true for the next node. Returns the last one, latched
+//
+// Should only be called when the read lock is held, with LOCKING_PER_NODE
+func (b *Bowl[k, v]) moveRightLatchedWhere(
+	node *Node[k, v], goesAfter func(next *Node[k, v]) bool) *Node[k, v] {
+	for {
+		next, _ := node.GetNextNodeAt(0)
+		if next == nil || !goesAfter(next) {
+			return node
+		}
+		next.latch.Lock()
+		node.latch.Unlock()
+		node = next
+	}
+}
+
+// splitLatched splits the full, latched `node`, links the new one at height 0,
+// and returns whichever of them `key` should go into, latched. The other one is unlatched.
+//
+// Only the node after them is latched, to fix its back-link. Linking above height 0
+// is left for linkTower once no latch is held, so the new node is added to pending
+//
+// Should only be called when the read lock is held, with LOCKING_PER_NODE
+func (b *Bowl[k, v]) splitLatched(node *Node[k, v], key k, pending *[]*Node[k, v]) *Node[k, v] {
+	newNode := node.SplitIntoNewNode(b.generateLevel())
+	next, _ := node.GetNextNodeAt(0)
+	newNode.ConnectNode(0, next)
+	newNode.ConnectPrevNode(node)
+	if next != nil {
+		next.latch.Lock()
+		next.ConnectPrevNode(newNode)
+		next.latch.Unlock()
+	}
+	node.ConnectNode(0, newNode)
+	*pending = append(*pending, newNode)
+
+	if newNode.checkKeyStrictlyLessThanLowKey(key) {
+		return node
+	}
+	newNode.latch.Lock()
+	node.latch.Unlock()
+	return newNode
+}
+
+// linkTower links the given nodes above height 0, by their low keys
+//
+// Should only be called when the read lock is held, with LOCKING_PER_NODE, and no latch
+func (b *Bowl[k, v]) linkTower(pending []*Node[k, v]) {
+	if len(pending) == 0 {
+		return
+	}
+	b.towerMu.Lock()
+	defer b.towerMu.Unlock()
+
+	for _, n := range pending {
+		node := b.head
+		for h := b.maxHeight - 1; h > 0; h-- {
+			// the first node has no low key, so it goes right after head
+			for n.hasLowKey {
+				next, _ := node.GetNextNodeAt(h)
+				if next == nil || next.checkKeyStrictlyLessThanLowKey(n.lowKey) {
+					break
+				}
+				node = next
+			}
+			if h < n.GetHeight() {
+				next, _ := node.GetNextNodeAt(h)
+				n.ConnectNode(h, next)
+				node.ConnectNode(h, n)
+			}
+		}
+	}
+}
+
+// forEachKeyLatched calls fn for every key in order, with the node that should has it latched.
+// When fn returns ErrNodeIsFull, the node is split, and fn is called again with the correct one.
+// Does nothing if this Bowl is empty, unless `create`
+//
+// Should only be called when the read lock is held, with LOCKING_PER_NODE
+func (b *Bowl[k, v]) forEachKeyLatched(
+	n int, keyAt func(int) k, create bool, fn func(i int, node *Node[k, v]) error) {
+	pending := make([]*Node[k, v], 0)
+	node := b.lockNodeWhere(keyAtOrAfterLowKey[k, v](keyAt(0)), create, &pending)
+	if node == nil {
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		key := keyAt(i)
+		node = b.moveRightLatchedWhere(node, keyAtOrAfterLowKey[k, v](key))
+		if fn(i, node) == ErrNodeIsFull {
+			node = b.splitLatched(node, key, &pending)
+			if err := fn(i, node); err != nil {
+				panic(fmt.Sprintf("Should be no error here, means something is broken: %v", err))
+			}
+		}
+	}
+	node.latch.Unlock()
+	b.linkTower(pending)
+}
+
+// The latched operations below should only be called when the read lock is held, with LOCKING_PER_NODE
+
+func (b *Bowl[k, v]) getLatched(keys []k, notFoundDefaultValue v, result []v) {
+	for i := range result {
+		result[i] = notFoundDefaultValue
+	}
+
+	b.forEachKeyLatched(len(keys), func(i int) k { return keys[i] }, false,
+		func(i int, node *Node[k, v]) error {
+			result[i], _ = node.Get(keys[i], notFoundDefaultValue)
+			return nil
+		})
+}
+
+func (b *Bowl[k, v]) insertLatched(ihs []Item[k, v], errs []error) {
+	b.forEachKeyLatched(len(ihs), func(i int) k { return ihs[i].Key }, true,
+		func(i int, node *Node[k, v]) error {
+			errs[i] = node.Insert(ihs[i])
+			return errs[i]
+		})
+}
+
+func (b *Bowl[k, v]) upsertLatched(ihs []Item[k, v], results []UpsertResult) {
+	b.forEachKeyLatched(len(ihs), func(i int) k { return ihs[i].Key }, true,
+		func(i int, node *Node[k, v]) error {
+			var err error
+			results[i], err = node.Upsert(ihs[i])
+			return err
+		})
+}
+
+func (b *Bowl[k, v]) updateLatched(ihs []Item[k, v], errs []error) {
+	b.forEachKeyLatched(len(ihs), func(i int) k { return ihs[i].Key }, true,
+		func(i int, node *Node[k, v]) error {
+			errs[i] = node.Update(ihs[i])
+			return nil
+		})
+}
+
+func (b *Bowl[k, v]) deleteLatched(keys []k, errs []error) {
+	b.forEachKeyLatched(len(keys), func(i int) k { return keys[i] }, true,
+		func(i int, node *Node[k, v]) error {
+			errs[i] = node.Delete(keys[i])
+			if node.GetCount() == 0 {
+				node.MarkRemoval()
+			}
+			return nil
+		})
+}
+
+func (b *Bowl[k, v]) scanBoundsLatched(ctx context.Context, bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	goesAfter := func(next *Node[k, v]) bool { return false }
+	if bounds.Lower.Type != UNBOUNDED {
+		goesAfter = keyAtOrAfterLowKey[k, v](bounds.Lower.Key)
+	}
+	node := b.lockNodeWhere(goesAfter, false, nil)
+	if node == nil {
+		return nil
+	}
+	defer func() { node.latch.Unlock() }()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !node.ScanBoundsWhile(bounds, fn) {
+			return nil
+		}
+		// everything after this node is bigger than its max
+		maxKey, err := node.GetMaxKey(bounds.Upper.Key)
+		if err == nil && !bounds.satisfiesUpper(b.cmp, maxKey) {
+			return nil
+		}
+		next, _ := node.GetNextNodeAt(0)
+		if next == nil {
+			return nil
+		}
+		next.latch.Lock()
+		node.latch.Unlock()
+		node = next
+	}
+}
+
+func (b *Bowl[k, v]) reverseScanBoundsLatched(ctx context.Context, bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	goesAfter := func(next *Node[k, v]) bool { return true }
+	if bounds.Upper.Type != UNBOUNDED {
+		goesAfter = keyAtOrAfterLowKey[k, v](bounds.Upper.Key)
+	}
+	node := b.lockNodeWhere(goesAfter, false, nil)
+	if node == nil {
+		return nil
+	}
+	defer func() { node.latch.Unlock() }()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !node.ReverseScanBoundsWhile(bounds, fn) {
+			return nil
+		}
+		// everything before this node is smaller than its min
+		minKey, err := node.GetMinKey(bounds.Lower.Key)
+		if err == nil && !bounds.satisfiesLower(b.cmp, minKey) {
+			return nil
+		}
+		prev := node.GetPrevNode()
+		if prev == b.head {
+			return nil
+		}
+
+		// latches are only taken left to right, so let go of this one first.
+		// prev may be split meanwhile, so move right again until right before this node's range
+		lowKey := node.lowKey
+		node.latch.Unlock()
+		prev.latch.Lock()
+		node = b.moveRightLatchedWhere(prev, func(next *Node[k, v]) bool {
+			return b.cmp(next.lowKey, lowKey) == -1
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package bowl
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+var lockingModes = map[string]LockingMode{
+	"global":   LOCKING_GLOBAL,
+	"per-node": LOCKING_PER_NODE,
+}
+
+// sortedMap is what every variant of Bowl has, for tests shared between them
+type sortedMap interface {
+	Get(keys []int, notFoundDefaultValue int) []int
+	Insert(ihs []Item[int, int]) []error
+	Update(ihs []Item[int, int]) []error
+	Upsert(ihs []Item[int, int]) []UpsertResult
+	Delete(keys []int) []error
+	ScanAll(fn func(Item[int, int]))
+	ReverseScanAll(fn func(Item[int, int]))
+	ScanRange(fromKey int, toKey int, fn func(Item[int, int]))
+	ReverseScanRange(fromKey int, toKey int, fn func(Item[int, int]))
+}
+
+func TestBowlLockingModes(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(8), WithSeed(42))
+		checkAgainstModel(t, name, b)
+		checkBowlLinks(t, b)
+	}
+}
+
+// checkAgainstModel runs random batches on m, and checks every read against a plain map
+func checkAgainstModel(t *testing.T, name string, m sortedMap) {
+	rnd := rand.New(rand.NewSource(42))
+	model := make(map[int]int)
+
+	for round := 0; round < 300; round++ {
+		ihs := make([]Item[int, int], 0, 64)
+		keys := make([]int, 0, 64)
+		for j := rnd.Intn(20); j < 2000; j += rnd.Intn(60) + 1 {
+			ihs = append(ihs, Item[int, int]{Key: j, Value: round})
+			keys = append(keys, j)
+		}
+
+		switch round % 4 {
+		case 0:
+			for i, err := range m.Insert(ihs) {
+				_, exist := model[ihs[i].Key]
+				if exist != (err == ErrKeyAlreadyExist) {
+					t.Fatalf("%s: Insert of key %d should fail only if it exists, but instead we got %v", name, ihs[i].Key, err)
+				}
+				if !exist {
+					model[ihs[i].Key] = round
+				}
+			}
+		case 1:
+			for i, err := range m.Update(ihs) {
+				if _, exist := model[ihs[i].Key]; exist {
+					if err != nil {
+						t.Fatalf("%s: Update of key %d should succeed, but instead we got %v", name, ihs[i].Key, err)
+					}
+					model[ihs[i].Key] = round
+				} else if err == nil {
+					t.Fatalf("%s: Update of missing key %d should fail, but it does not", name, ihs[i].Key)
+				}
+			}
+		case 2:
+			for i, result := range m.Upsert(ihs) {
+				if _, exist := model[ihs[i].Key]; exist != (result == UPSERT_REPLACED) {
+					t.Fatalf("%s: Upsert of key %d should replace only if it exists, but instead we got %v", name, ihs[i].Key, result)
+				}
+				model[ihs[i].Key] = round
+			}
+		case 3:
+			for i, err := range m.Delete(keys) {
+				if _, exist := model[keys[i]]; exist != (err == nil) {
+					t.Fatalf("%s: Delete of key %d should succeed only if it exists, but instead we got %v", name, keys[i], err)
+				}
+				delete(model, keys[i])
+			}
+		}
+
+		expected := make([]Item[int, int], 0, len(model))
+		allKeys := make([]int, 0, 2000)
+		for i := 0; i < 2000; i++ {
+			allKeys = append(allKeys, i)
+			if value, ok := model[i]; ok {
+				expected = append(expected, Item[int, int]{Key: i, Value: value})
+			}
+		}
+		stage := fmt.Sprintf("%s at round %d", name, round)
+		content := make([]Item[int, int], 0, len(expected))
+		m.ScanAll(func(ih Item[int, int]) {
+			content = append(content, ih)
+		})
+		checkSameContent(t, stage, expected, content)
+
+		for i, value := range m.Get(allKeys, math.MinInt) {
+			if expectedValue, ok := model[i]; ok && value != expectedValue || !ok && value != math.MinInt {
+				t.Fatalf("%s: Get of key %d is wrong, we got %d", stage, i, value)
+			}
+		}
+
+		reversed := make([]Item[int, int], 0, len(expected))
+		m.ReverseScanAll(func(ih Item[int, int]) {
+			reversed = append(reversed, ih)
+		})
+		for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+			reversed[i], reversed[j] = reversed[j], reversed[i]
+		}
+		checkSameContent(t, stage+" reversed", expected, reversed)
+
+		lo, hi := rnd.Intn(2000), rnd.Intn(2000)
+		inRange := make([]Item[int, int], 0)
+		for _, ih := range expected {
+			if ih.Key >= lo && ih.Key <= hi {
+				inRange = append(inRange, ih)
+			}
+		}
+		got := make([]Item[int, int], 0)
+		m.ScanRange(lo, hi, func(ih Item[int, int]) { got = append(got, ih) })
+		checkSameContent(t, stage+" range", inRange, got)
+		got = got[:0]
+		m.ReverseScanRange(lo, hi, func(ih Item[int, int]) { got = append([]Item[int, int]{ih}, got...) })
+		checkSameContent(t, stage+" reversed range", inRange, got)
+	}
+}
+
+func TestBowlPerNodeConcurrentWriters(t *testing.T) {
+	// run with -race, writers on disjoint ranges only meet at the nodes in between
+	b := NewBOWL[int, int](cmpTest, WithLockingMode(LOCKING_PER_NODE), WithNodeSize(16))
+	const writers = 8
+	const perWriter = 2000
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				prev := -1
+				b.ScanAll(func(ih Item[int, int]) {
+					if ih.Key <= prev {
+						t.Errorf("Scan should be ascending, but %d came after %d", ih.Key, prev)
+					}
+					prev = ih.Key
+				})
+				next := math.MaxInt
+				b.ReverseScanAll(func(ih Item[int, int]) {
+					if ih.Key >= next {
+						t.Errorf("Reverse scan should be descending, but %d came after %d", ih.Key, next)
+					}
+					next = ih.Key
+				})
+			}
+		}()
+	}
+
+	var writersWg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersWg.Add(1)
+		go func(w int) {
+			defer writersWg.Done()
+			// interleaved keys, so every batch crosses the others' nodes
+			for i := 0; i < perWriter; i += 100 {
+				ihs := make([]Item[int, int], 0, 100)
+				for j := i; j < i+100; j++ {
+					key := j*writers + w
+					ihs = append(ihs, Item[int, int]{Key: key, Value: key})
+				}
+				for _, err := range b.Insert(ihs) {
+					if err != nil {
+						t.Errorf("Insert should succeed, but instead we got %v", err)
+					}
+				}
+				if i%300 == 0 {
+					keys := make([]int, 0, 50)
+					for _, ih := range ihs[:50] {
+						keys = append(keys, ih.Key)
+					}
+					b.Delete(keys)
+					b.Insert(ihs[:50])
+				}
+			}
+		}(w)
+	}
+	writersWg.Wait()
+	close(done)
+	readers.Wait()
+
+	content := snapshotContent(b)
+	if len(content) != writers*perWriter {
+		t.Fatalf("It should have %d data, but instead we got %d", writers*perWriter, len(content))
+	}
+	for i, ih := range content {
+		if ih.Key != i || ih.Value != i {
+			t.Fatalf("At iter %d it should be %d, but instead we got %v", i, i, ih)
+		}
+	}
+	checkBowlLinks(t, b)
+}
+
+func TestBowlPerNodeWithExclusiveOperations(t *testing.T) {
+	// operations holding the whole Bowl should still see a consistent Bowl
+	b := NewBOWL[int, int](cmpTest, WithLockingMode(LOCKING_PER_NODE), WithNodeSize(8))
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				keys := make([]int, 0, 10)
+				for j := 0; j < 10; j++ {
+					keys = append(keys, (i*10+j)*4+w)
+				}
+				if w%2 == 0 {
+					b.Apply(keys, func(key int, old int, exists bool) (int, Action) {
+						return key, ACTION_SET
+					})
+				} else {
+					ihs := make([]Item[int, int], 0, 10)
+					for _, key := range keys {
+						ihs = append(ihs, Item[int, int]{Key: key, Value: key})
+					}
+					b.Insert(ihs)
+				}
+				c := b.NewCursor()
+				for ok := c.First(); ok; ok = c.Next() {
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	content := snapshotContent(b)
+	if len(content) != 4000 {
+		t.Fatalf("It should have 4000 data, but instead we got %d", len(content))
+	}
+	for i, ih := range content {
+		if ih.Key != i {
+			t.Fatalf("At iter %d it should be %d, but instead we got %v", i, i, ih)
+		}
+	}
+	checkBowlLinks(t, b)
+}
+
+// BenchmarkBowlWriteParallel has every goroutine inserting into its own key range
+func BenchmarkBowlWriteParallel(b *testing.B) {
+	for name, mode := range lockingModes {
+		b.Run(name, func(b *testing.B) {
+			bowl := NewBOWL[int, int](cmpTest, WithLockingMode(mode))
+			var nextRange sync.Mutex
+			rangeStart := 0
+			b.RunParallel(func(pb *testing.PB) {
+				nextRange.Lock()
+				start := rangeStart
+				rangeStart += 1 << 40
+				nextRange.Unlock()
+
+				data := make([]Item[int, int], 1024)
+				for pb.Next() {
+					for j := range data {
+						start += 1
+						data[j] = Item[int, int]{Key: start, Value: start}
+					}
+					bowl.Insert(data)
+				}
+			})
+		})
+	}
+}
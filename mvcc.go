@@ -0,0 +1,352 @@
+package bowl
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+)
+
+var ErrSeqCollected = errors.New("Versions as of given sequence are not kept anymore")
+
+// versionState is shared by a Bowl and all its nodes
+type versionState struct {
+	// seq is the sequence number of the latest write
+	seq atomic.Uint64
+	// retain keeps older versions, see WithMVCC
+	retain bool
+	// oldest is the smallest sequence still readable with retain, moved by CollectGarbage
+	oldest atomic.Uint64
+}
+
+// version is an older version of an item, visible as of item.Seq until right before endSeq,
+// the sequence number of the write that replaced or deleted it
+type version[k comparable, v any] struct {
+	item   Item[k, v]
+	endSeq uint64
+}
+
+func (ver version[k, v]) visibleAsOf(asOf uint64) bool {
+	return ver.item.Seq <= asOf && asOf < ver.endSeq
+}
+
+// nextSeq returns the sequence number for a new write, or 0 for a standalone node
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) nextSeq() uint64 {
+	if n.versions == nil {
+		return 0
+	}
+	return n.versions.seq.Add(1)
+}
+
+// retire keeps ih as an older version ending at endSeq, only with WithMVCC
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) retire(ih Item[k, v], endSeq uint64) {
+	if n.versions != nil && n.versions.retain {
+		n.history = append(n.history, version[k, v]{item: ih, endSeq: endSeq})
+	}
+}
+
+// replaceAt sets the value at idx as a new write, retiring the current one
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) replaceAt(idx int, value v) {
+	n.own()
+	seq := n.nextSeq()
+	n.retire(n.data[idx], seq)
+	n.data[idx].Value = value
+	n.data[idx].Seq = seq
+}
+
+// splitHistory returns the older versions of keys below lowKey, and of the others
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) splitHistory(lowKey k) ([]version[k, v], []version[k, v]) {
+	if len(n.history) == 0 {
+		return nil, nil
+	}
+	lower := n.history[:0:0]
+	upper := n.history[:0:0]
+	for _, ver := range n.history {
+		if n.cmp(ver.item.Key, lowKey) == -1 {
+			lower = append(lower, ver)
+		} else {
+			upper = append(upper, ver)
+		}
+	}
+	return lower, upper
+}
+
+// hasVersions checks whether this node has data, or older versions
+func (n *Node[k, v]) hasVersions() bool {
+	return n.dataCount > 0 || len(n.history) > 0
+}
+
+// getAsOf returns the item of key visible as of the given sequence, if any
+func (n *Node[k, v]) getAsOf(key k, asOf uint64) (Item[k, v], bool) {
+	if ih, ok := n.getItem(key); ok && ih.Seq <= asOf {
+		return ih, true
+	}
+	for _, ver := range n.history {
+		if n.cmp(ver.item.Key, key) == 0 && ver.visibleAsOf(asOf) {
+			return ver.item, true
+		}
+	}
+	return Item[k, v]{}, false
+}
+
+// itemsAsOf returns all items visible as of the given sequence, ascending-sorted
+func (n *Node[k, v]) itemsAsOf(asOf uint64) []Item[k, v] {
+	result := make([]Item[k, v], 0, n.dataCount)
+	for _, ih := range n.data[:n.dataCount] {
+		if ih.Seq <= asOf {
+			result = append(result, ih)
+		}
+	}
+	if len(n.history) == 0 {
+		return result
+	}
+	for _, ver := range n.history {
+		if ver.visibleAsOf(asOf) {
+			result = append(result, ver.item)
+		}
+	}
+	// each key has at most 1 version visible at a time
+	slices.SortFunc(result, func(a, b Item[k, v]) int {
+		return n.cmp(a.Key, b.Key)
+	})
+	return result
+}
+
+// collectHistory drops the older versions not visible as of upTo or later
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) collectHistory(upTo uint64) {
+	kept := n.history[:0]
+	for _, ver := range n.history {
+		if ver.endSeq > upTo {
+			kept = append(kept, ver)
+		}
+	}
+	clear(n.history[len(kept):])
+	n.history = kept
+	if len(n.history) == 0 {
+		n.history = nil
+	}
+}
+
+// LastSeq returns the sequence number of the latest write.
+// Every successful write of a single item takes the next one, starting from 1
+func (b *Bowl[k, v]) LastSeq() uint64 {
+	return b.versions.seq.Load()
+}
+
+// checkReadableAsOf returns ErrSeqCollected if the versions as of the given sequence may not be kept.
+// Without WithMVCC, only the latest one is
+//
+// Should only be called when readLock is held
+func (b *Bowl[k, v]) checkReadableAsOf(asOf uint64) error {
+	oldest := b.versions.oldest.Load()
+	if !b.versions.retain {
+		oldest = b.versions.seq.Load()
+	}
+	if asOf < oldest {
+		return ErrSeqCollected
+	}
+	return nil
+}
+
+// getNextNodeAtHeightWithVersions returns the first node after `node` at height h
+// having data or older versions, or nil if there is none
+func (b *Bowl[k, v]) getNextNodeAtHeightWithVersions(h int, node *Node[k, v]) *Node[k, v] {
+	next, _ := node.GetNextNodeAt(h)
+	for next != nil && !next.hasVersions() {
+		next, _ = next.GetNextNodeAt(h)
+	}
+	return next
+}
+
+// getNodeForReadAsOf returns the node covering key, or nil if there is none.
+// Unlike getNodeForRead, nodes having only older versions count
+func (b *Bowl[k, v]) getNodeForReadAsOf(key k) *Node[k, v] {
+	node := b.head
+	for h := b.maxHeight - 1; h >= 0; h-- {
+		for {
+			next := b.getNextNodeAtHeightWithVersions(h, node)
+			if next == nil || next.checkKeyStrictlyLessThanLowKey(key) {
+				break
+			}
+			node = next
+		}
+	}
+	if node == b.head { // key is before every node
+		return b.getNextNodeAtHeightWithVersions(0, b.head)
+	}
+	return node
+}
+
+// getLastNodeAsOf returns the last node having data or older versions, or nil if there is none
+func (b *Bowl[k, v]) getLastNodeAsOf() *Node[k, v] {
+	node := b.head
+	for h := b.maxHeight - 1; h >= 0; h-- {
+		for next := b.getNextNodeAtHeightWithVersions(h, node); next != nil; next = b.getNextNodeAtHeightWithVersions(h, node) {
+			node = next
+		}
+	}
+	if node == b.head {
+		return nil
+	}
+	return node
+}
+
+// GetAsOf returns all values for the given keys, as they were right after the write with sequence asOf.
+// Returns ErrSeqCollected if the versions as of asOf are not kept, see WithMVCC and CollectGarbage
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed.
+// With LOCKING_PER_NODE, it holds the whole Bowl, and the writes of a batch are not isolated,
+// so a sequence in the middle of a batch running concurrently may only show part of it
+func (b *Bowl[k, v]) GetAsOf(keys []k, asOf uint64, notFoundDefaultValue v) ([]v, error) {
+	if err := b.validateKeys(keys); err != nil {
+		return nil, err
+	}
+	result := make([]v, len(keys))
+
+	b.readLock()
+	defer b.readUnlock()
+	if err := b.checkReadableAsOf(asOf); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	node := b.getNodeForReadAsOf(keys[0])
+	for i, key := range keys {
+		result[i] = notFoundDefaultValue
+		if node == nil {
+			continue
+		}
+		for next := b.getNextNodeAtHeightWithVersions(0, node); next != nil && !next.checkKeyStrictlyLessThanLowKey(key); next = b.getNextNodeAtHeightWithVersions(0, node) {
+			node = next
+		}
+		if ih, ok := node.getAsOf(key, asOf); ok {
+			result[i] = ih.Value
+		}
+	}
+	return result, nil
+}
+
+// ScanAllAsOf pass each data visible as of asOf to fn, see ScanBoundsAsOfWhile
+func (b *Bowl[k, v]) ScanAllAsOf(asOf uint64, fn func(Item[k, v])) error {
+	return b.ScanBoundsAsOfWhile(Bounds[k]{}, asOf, alwaysContinue(fn))
+}
+
+// ScanRangeAsOf pass each data visible as of asOf between fromKey <= data <= toKey, see ScanBoundsAsOfWhile
+func (b *Bowl[k, v]) ScanRangeAsOf(fromKey k, toKey k, asOf uint64, fn func(Item[k, v])) error {
+	return b.ScanBoundsAsOfWhile(
+		Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, asOf, alwaysContinue(fn))
+}
+
+// ScanBoundsAsOfWhile pass each data inside `bounds`, as it was right after the write with sequence asOf, to fn,
+// and stops as soon as fn returns false. Each Item has the Seq of that version.
+// Returns ErrSeqCollected if the versions as of asOf are not kept, see WithMVCC and CollectGarbage
+func (b *Bowl[k, v]) ScanBoundsAsOfWhile(bounds Bounds[k], asOf uint64, fn func(Item[k, v]) bool) error {
+	b.readLock()
+	defer b.readUnlock()
+	if err := b.checkReadableAsOf(asOf); err != nil {
+		return err
+	}
+
+	var node *Node[k, v]
+	if bounds.Lower.Type == UNBOUNDED {
+		node = b.getNextNodeAtHeightWithVersions(0, b.head)
+	} else {
+		node = b.getNodeForReadAsOf(bounds.Lower.Key)
+	}
+
+	for node != nil {
+		for _, ih := range node.itemsAsOf(asOf) {
+			if !bounds.satisfiesLower(b.cmp, ih.Key) {
+				continue
+			}
+			if !bounds.satisfiesUpper(b.cmp, ih.Key) || !fn(ih) {
+				return nil
+			}
+		}
+		node = b.getNextNodeAtHeightWithVersions(0, node)
+		// everything from the next node is at or after its low key
+		if node != nil && !bounds.satisfiesUpper(b.cmp, node.lowKey) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ReverseScanAllAsOf pass each data visible as of asOf to fn, in descending order, see ScanBoundsAsOfWhile
+func (b *Bowl[k, v]) ReverseScanAllAsOf(asOf uint64, fn func(Item[k, v])) error {
+	return b.ReverseScanBoundsAsOfWhile(Bounds[k]{}, asOf, alwaysContinue(fn))
+}
+
+// ReverseScanRangeAsOf pass each data visible as of asOf between fromKey <= data <= toKey to fn,
+// in descending order, see ScanBoundsAsOfWhile
+func (b *Bowl[k, v]) ReverseScanRangeAsOf(fromKey k, toKey k, asOf uint64, fn func(Item[k, v])) error {
+	return b.ReverseScanBoundsAsOfWhile(
+		Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, asOf, alwaysContinue(fn))
+}
+
+// ReverseScanBoundsAsOfWhile is ScanBoundsAsOfWhile, in descending order
+func (b *Bowl[k, v]) ReverseScanBoundsAsOfWhile(bounds Bounds[k], asOf uint64, fn func(Item[k, v]) bool) error {
+	b.readLock()
+	defer b.readUnlock()
+	if err := b.checkReadableAsOf(asOf); err != nil {
+		return err
+	}
+
+	var node *Node[k, v]
+	if bounds.Upper.Type == UNBOUNDED {
+		node = b.getLastNodeAsOf()
+	} else {
+		node = b.getNodeForReadAsOf(bounds.Upper.Key)
+	}
+
+	for node != nil && node != b.head {
+		items := node.itemsAsOf(asOf)
+		for i := len(items) - 1; i >= 0; i-- {
+			if !bounds.satisfiesUpper(b.cmp, items[i].Key) {
+				continue
+			}
+			if !bounds.satisfiesLower(b.cmp, items[i].Key) || !fn(items[i]) {
+				return nil
+			}
+		}
+		// everything before this node is before its low key
+		if !node.hasLowKey || !bounds.satisfiesLower(b.cmp, node.lowKey) {
+			return nil
+		}
+		node = node.GetPrevNode()
+	}
+	return nil
+}
+
+// CollectGarbage drops the older versions not visible as of upTo or any later sequence,
+// and makes the reads as of before upTo return ErrSeqCollected from now on.
+// upTo above LastSeq is taken as LastSeq.
+//
+// It holds the whole Bowl while going through every node
+func (b *Bowl[k, v]) CollectGarbage(upTo uint64) {
+	b.Lock()
+	defer b.Unlock()
+
+	upTo = min(upTo, b.versions.seq.Load())
+	if upTo <= b.versions.oldest.Load() {
+		return
+	}
+	b.versions.oldest.Store(upTo)
+
+	node, _ := b.head.GetNextNodeAt(0)
+	for node != nil {
+		node.collectHistory(upTo)
+		node, _ = node.GetNextNodeAt(0)
+	}
+}
@@ -0,0 +1,158 @@
+package bowl
+
+import (
+	"math"
+	"testing"
+)
+
+// asOfContent returns all data of b visible as of asOf, in order
+func asOfContent(t *testing.T, b *Bowl[int, int], asOf uint64) []Item[int, int] {
+	t.Helper()
+	content := make([]Item[int, int], 0)
+	if err := b.ScanAllAsOf(asOf, func(ih Item[int, int]) {
+		content = append(content, ih)
+	}); err != nil {
+		t.Fatalf("ScanAllAsOf %d should succeed, but instead we got %v", asOf, err)
+	}
+	return content
+}
+
+func TestBowlMVCCAsOfReads(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(8), WithMVCC())
+		seqs := make([]uint64, 0)
+		contents := make([][]Item[int, int], 0)
+		record := func() {
+			seqs = append(seqs, b.LastSeq())
+			contents = append(contents, snapshotContent(b))
+		}
+		record()
+
+		ihs := make([]Item[int, int], 0, 200)
+		for i := 0; i < 400; i += 2 {
+			ihs = append(ihs, Item[int, int]{Key: i, Value: i, Seq: math.MaxUint64})
+		}
+		b.Insert(ihs)
+		record()
+		// emptying whole nodes, so they are kept only for their older versions
+		deleted := make([]int, 0, 100)
+		for i := 100; i < 300; i += 2 {
+			deleted = append(deleted, i)
+		}
+		b.Delete(deleted)
+		record()
+		b.Update([]Item[int, int]{{Key: 0, Value: -1}, {Key: 398, Value: -1}})
+		record()
+		odd := make([]Item[int, int], 0, 200)
+		for i := 1; i < 400; i += 2 {
+			odd = append(odd, Item[int, int]{Key: i, Value: i})
+		}
+		b.Upsert(append([]Item[int, int]{{Key: 0, Value: -2}}, odd...))
+		record()
+		b.Insert([]Item[int, int]{{Key: 150, Value: 150}})
+		record()
+
+		for i := 1; i < len(seqs); i++ {
+			if seqs[i] <= seqs[i-1] {
+				t.Fatalf("%s: LastSeq should increase on every write, but went from %d to %d", name, seqs[i-1], seqs[i])
+			}
+		}
+		for i, asOf := range seqs {
+			checkSameContent(t, name+" as of", contents[i], asOfContent(t, b, asOf))
+
+			reversed := make([]Item[int, int], 0)
+			b.ReverseScanRangeAsOf(50, 350, asOf, func(ih Item[int, int]) {
+				reversed = append([]Item[int, int]{ih}, reversed...)
+			})
+			expected := make([]Item[int, int], 0)
+			for _, ih := range contents[i] {
+				if ih.Key >= 50 && ih.Key <= 350 {
+					expected = append(expected, ih)
+				}
+			}
+			checkSameContent(t, name+" reversed range as of", expected, reversed)
+
+			values, err := b.GetAsOf([]int{0, 1, 100, 150, 398, 1000}, asOf, math.MinInt)
+			if err != nil {
+				t.Fatalf("%s: GetAsOf %d should succeed, but instead we got %v", name, asOf, err)
+			}
+			for j, key := range []int{0, 1, 100, 150, 398, 1000} {
+				expectedValue := math.MinInt
+				for _, ih := range contents[i] {
+					if ih.Key == key {
+						expectedValue = ih.Value
+					}
+				}
+				if values[j] != expectedValue {
+					t.Fatalf("%s: GetAsOf %d of key %d should be %d, but instead we got %d",
+						name, asOf, key, expectedValue, values[j])
+				}
+			}
+		}
+
+		// Seq tells which write set the value
+		latest := asOfContent(t, b, b.LastSeq())
+		if latest[0].Key != 0 || latest[0].Seq <= seqs[3] || latest[0].Seq > seqs[4] {
+			t.Fatalf("%s: key 0 should have the Seq of the Upsert, but instead we got %v", name, latest[0])
+		}
+		checkBowlLinks(t, b)
+	}
+}
+
+func TestBowlMVCCCollectGarbage(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(4), WithMVCC())
+		ihs := make([]Item[int, int], 0, 100)
+		keys := make([]int, 0, 100)
+		for i := 0; i < 100; i++ {
+			ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+			keys = append(keys, i)
+		}
+		b.Insert(ihs)
+		inserted := b.LastSeq()
+		b.Delete(keys)
+		deleted := b.LastSeq()
+
+		b.CollectGarbage(inserted)
+		if got := asOfContent(t, b, inserted); len(got) != 100 {
+			t.Fatalf("%s: Data as of the collected sequence should be kept, but instead we got %d", name, len(got))
+		}
+		if _, err := b.GetAsOf([]int{0}, inserted-1, 0); err != ErrSeqCollected {
+			t.Fatalf("%s: GetAsOf before collected sequence should fail, but instead we got %v", name, err)
+		}
+		if err := b.ScanAllAsOf(inserted-1, func(Item[int, int]) {}); err != ErrSeqCollected {
+			t.Fatalf("%s: ScanAllAsOf before collected sequence should fail, but instead we got %v", name, err)
+		}
+
+		b.CollectGarbage(math.MaxUint64)
+		if got := asOfContent(t, b, deleted); len(got) != 0 {
+			t.Fatalf("%s: Bowl should be empty after deleting all, but instead we got %d", name, len(got))
+		}
+		b.Insert([]Item[int, int]{{Key: 1, Value: 1}})
+		for node, _ := b.head.GetNextNodeAt(0); node != nil; node, _ = node.GetNextNodeAt(0) {
+			if len(node.history) != 0 {
+				t.Fatalf("%s: CollectGarbage up to last sequence should drop all older versions, but some are kept", name)
+			}
+		}
+		checkSameContent(t, name+" after collecting", []Item[int, int]{{Key: 1, Value: 1}}, snapshotContent(b))
+		checkBowlLinks(t, b)
+	}
+}
+
+func TestBowlMVCCNotRetained(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	b.Insert([]Item[int, int]{{Key: 1, Value: 1}})
+	b.Update([]Item[int, int]{{Key: 1, Value: 2}})
+
+	values, err := b.GetAsOf([]int{1}, b.LastSeq(), 0)
+	if err != nil || values[0] != 2 {
+		t.Fatalf("GetAsOf the latest sequence should always work, but instead we got %v %v", values, err)
+	}
+	if _, err := b.GetAsOf([]int{1}, b.LastSeq()-1, 0); err != ErrSeqCollected {
+		t.Fatalf("Without WithMVCC, older sequences should not be readable, but instead we got %v", err)
+	}
+	node, _ := b.head.GetNextNodeAt(0)
+	if len(node.history) != 0 {
+		t.Fatalf("Without WithMVCC, older versions should not be kept, but instead we got %d", len(node.history))
+	}
+}
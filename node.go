@@ -2,10 +2,12 @@ package bowl
 
 import (
 	"errors"
+	"sync"
 )
 
 const (
-	NODE_SIZE int = 256
+	NODE_SIZE   int     = 256
+	SPLIT_RATIO float64 = 0.5
 )
 
 var ErrKeyAlreadyExist = errors.New("Given key is already exist")
@@ -14,16 +16,35 @@ var ErrNodeIsEmpty = errors.New("Node is empty")
 var ErrDataNotFound = errors.New("Given data is not in this node")
 var ErrHeightOutsideRange = errors.New("This node's height is lower than given height")
 
+// UpsertResult tells whether Upsert created a new item or replaced an existing one
+type UpsertResult int
+
+const (
+	UPSERT_CREATED  UpsertResult = 0
+	UPSERT_REPLACED UpsertResult = 1
+	// UPSERT_FAILED means nothing is applied, as the write-ahead log failed, see Bowl.TryUpsert
+	UPSERT_FAILED UpsertResult = 2
+)
+
 // Item wraps key-value pair into single object
 type Item[k comparable, v any] struct {
 	Key   k
 	Value v
+	// Seq is the sequence number of the write that set Value, given by the Bowl.
+	// It is ignored on writes
+	Seq uint64
 }
 
-// Node holds a slice of at most NODE_SIZE data
+// Node holds a slice of at most NODE_SIZE data, or the size given on creation
 //
 // For deletion, the node is MARKED_REMOVAL, for now
 //
+// Every node except the first has a low key, set when it is split off,
+// which never changes afterwards. The node covers keys from its low key
+// until the next node's low key, even when its data is deleted,
+// so the upper layer can find the correct node without looking at the data.
+//
+// With LOCKING_PER_NODE, its latch guards data, state, and the links at height 0.
 // For now, it uses sync.Mutex for simplicity.
 // As algorithm and implementation becomes more settled,
 // will change to single int for lock, among others
@@ -37,36 +58,60 @@ type Node[k comparable, v any] struct {
 	data      []Item[k, v]
 	height    int
 	nextNodes []*Node[k, v]
+
+	// splitRatio is the portion of data staying in this node on SplitIntoNewNode
+	splitRatio float64
+
+	// prevNode is the node before this one at height 0, used for descending scans
+	prevNode *Node[k, v]
+
+	// lowKey is the smallest key this node covers, only when hasLowKey
+	lowKey    k
+	hasLowKey bool
+
+	// latch is only used with LOCKING_PER_NODE
+	latch sync.Mutex
+
+	// snapshots is shared by every node of a Bowl, nil for a standalone node.
+	// ownedEpoch is the snapshot epoch data was last copied at, see own
+	snapshots  *snapshotState
+	ownedEpoch uint64
+
+	// versions is shared by every node of a Bowl, nil for a standalone node.
+	// history has the older versions of the keys this node covers, in no particular order,
+	// only kept with WithMVCC
+	versions *versionState
+	history  []version[k, v]
 }
 
 // NewEmptyNode creates Node with height h and given comparator
 func NewEmptyNode[k comparable, v any](h int, cmp Comparator[k]) *Node[k, v] {
-	return &Node[k, v]{
-		state:     ACTIVE,
-		cmp:       cmp,
-		dataCount: 0,
-		data:      make([]Item[k, v], NODE_SIZE),
-		height:    h,
-		nextNodes: make([]*Node[k, v], h),
-	}
+	return newNode[k, v](h, NODE_SIZE, SPLIT_RATIO, cmp)
 }
 
 // NewNodeWithOrderedSlice creates Node with height h, given initial data and comparator
 func NewNodeWithOrderedSlice[k comparable, v any](
 	h int, data []Item[k, v], size int, cmp Comparator[k]) *Node[k, v] {
-	n := &Node[k, v]{
-		state:     ACTIVE,
-		cmp:       cmp,
-		dataCount: 0,
-		data:      make([]Item[k, v], NODE_SIZE),
-		height:    h,
-		nextNodes: make([]*Node[k, v], h),
-	}
+	n := newNode[k, v](h, NODE_SIZE, SPLIT_RATIO, cmp)
 	copy(n.data, data[:size])
 	n.dataCount = size
 	return n
 }
 
+// newNode creates an empty Node with height h, holding at most `nodeSize` data
+func newNode[k comparable, v any](
+	h int, nodeSize int, splitRatio float64, cmp Comparator[k]) *Node[k, v] {
+	return &Node[k, v]{
+		state:      ACTIVE,
+		cmp:        cmp,
+		dataCount:  0,
+		data:       make([]Item[k, v], nodeSize),
+		height:     h,
+		nextNodes:  make([]*Node[k, v], h),
+		splitRatio: splitRatio,
+	}
+}
+
 // GetHeight returns n.height
 //
 // Should only be called when Lock is held, or when no concurrency is guaranteed
@@ -165,9 +210,14 @@ func (n *Node[k, v]) Insert(ih Item[k, v]) error {
 	if idx != -1 {
 		return ErrKeyAlreadyExist
 	}
-	if n.dataCount == NODE_SIZE {
+	if n.dataCount == len(n.data) {
 		return ErrNodeIsFull
 	}
+	// a node marked removal may still be picked up by the upper layer,
+	// e.g. when it is the only node left, so having data makes it alive again
+	n.state = ACTIVE
+	n.own()
+	ih.Seq = n.nextSeq()
 	idx = n.GetPositionLessThanEqual(ih.Key)
 	if idx == -1 {
 		n.data[n.dataCount] = ih
@@ -179,6 +229,19 @@ func (n *Node[k, v]) Insert(ih Item[k, v]) error {
 	return nil
 }
 
+// Upsert inserts ih into current node, or replaces the value if ih.Key already exists.
+// Whether this node is the correct node, is left for the upper layer
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) Upsert(ih Item[k, v]) (UpsertResult, error) {
+	idx := n.GetPositionExact(ih.Key)
+	if idx != -1 {
+		n.replaceAt(idx, ih.Value)
+		return UPSERT_REPLACED, nil
+	}
+	return UPSERT_CREATED, n.Insert(ih)
+}
+
 // Delete the specified key, if any
 //
 // Should only be called when Lock is held, or when no concurrency is guaranteed
@@ -190,9 +253,15 @@ func (n *Node[k, v]) Delete(key k) error {
 	if idx == -1 {
 		return ErrDataNotFound
 	}
+	n.removeAt(idx)
+	return nil
+}
+
+func (n *Node[k, v]) removeAt(idx int) {
+	n.own()
+	n.retire(n.data[idx], n.nextSeq())
 	n.dataCount--
 	copy(n.data[idx:n.dataCount], n.data[idx+1:n.dataCount+1])
-	return nil
 }
 
 // Update the itemHandle for d.Key into d
@@ -206,10 +275,19 @@ func (n *Node[k, v]) Update(d Item[k, v]) error {
 	if idx == -1 {
 		return ErrDataNotFound
 	}
-	n.data[idx].Value = d.Value
+	n.replaceAt(idx, d.Value)
 	return nil
 }
 
+// getItem returns the whole item for the specified key, and whether it exists
+func (n *Node[k, v]) getItem(key k) (Item[k, v], bool) {
+	idx := n.GetPositionExact(key)
+	if idx == -1 {
+		return Item[k, v]{}, false
+	}
+	return n.data[idx], true
+}
+
 // Get returns the value for the specified key, if any
 //
 // Should only be called when Lock is held, or when no concurrency is guaranteed
@@ -265,6 +343,12 @@ func (n *Node[k, v]) CheckKeyStrictlyLessThanMin(key k) (bool, error) {
 	return n.cmp(key, n.data[0].Key) == -1, nil
 }
 
+// checkKeyStrictlyLessThanLowKey checks whether key is less than the low key of this node.
+// The first node has no low key, so it never is
+func (n *Node[k, v]) checkKeyStrictlyLessThanLowKey(key k) bool {
+	return n.hasLowKey && n.cmp(key, n.lowKey) == -1
+}
+
 // ConnectNode set nextNodes at height `atHeight` to `next`
 //
 // Should only be called when Lock is held, or when no concurrency is guaranteed
@@ -288,6 +372,20 @@ func (n *Node[k, v]) DisconnectNode(atHeight int) error {
 	return nil
 }
 
+// ConnectPrevNode set the node before this one at height 0 to `prev`
+//
+// Should only be called either when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) ConnectPrevNode(prev *Node[k, v]) {
+	n.prevNode = prev
+}
+
+// GetPrevNode returns the node before this one at height 0
+//
+// Should only be called either when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) GetPrevNode() *Node[k, v] {
+	return n.prevNode
+}
+
 // GetNextNodeAt returns the next node at the given `atHeight`
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
@@ -302,87 +400,160 @@ func (n *Node[k, v]) GetNextNodeAt(atHeight int) (*Node[k, v], error) {
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
 func (n *Node[k, v]) ScanAll(fn func(Item[k, v])) {
-	for i := 0; i < n.GetCount(); i++ {
-		fn(n.data[i])
-	}
+	n.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
 }
 
 // ScanGreaterThanEqual pass each data greater than `key` to fn
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
 func (n *Node[k, v]) ScanGreaterThanEqual(key k, fn func(Item[k, v])) {
-	ok, _ := n.CheckKeyStrictlyLessThanMax(key)
-	if !ok {
-		return
-	}
-	idx := n.GetPositionGreaterThanEqual(key)
-	if idx != -1 {
-		for i := idx; i < n.GetCount(); i++ {
-			fn(n.data[i])
-		}
-	} else {
-		//much lower than min
-		n.ScanAll(fn)
-	}
+	n.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
 }
 
 // ScanStrictlyLessThan pass each data strictly less than `key` to fn
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
 func (n *Node[k, v]) ScanStrictlyLessThan(key k, fn func(Item[k, v])) {
-	ok, _ := n.CheckKeyStrictlyLessThanMin(key)
-	if ok {
-		return
-	}
-	idx := n.GetPositionLessThanEqual(key)
-	if idx != -1 {
-		for i := 0; i < idx; i++ {
-			fn(n.data[i])
+	n.ScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanRange pass each data between `fromKey` <= data <= `toKey` to fn
+//
+// Should only be called either when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) ScanRange(fromKey, toKey k, fn func(Item[k, v])) {
+	n.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanBoundsWhile pass each data inside `bounds` to fn, until fn returns false.
+// Returns false if fn stopped the scan
+//
+// Should only be called either when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) bool {
+	to := n.getPositionAfterUpper(bounds)
+	for i := n.getPositionOfLower(bounds); i < to; i++ {
+		if !fn(n.data[i]) {
+			return false
 		}
-	} else {
-		// much bigger than contents
-		n.ScanAll(fn)
 	}
+	return true
 }
 
-// ScanRange pass each data in between `fromKey` and `toKey`
+// ReverseScanBoundsWhile pass each data inside `bounds` to fn in descending order, until fn returns false.
+// Returns false if fn stopped the scan
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
-func (n *Node[k, v]) ScanRange(fromKey, toKey k, fn func(Item[k, v])) {
-	ok, _ := n.CheckKeyStrictlyLessThanMin(toKey)
-	if ok {
-		return
+func (n *Node[k, v]) ReverseScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) bool {
+	from := n.getPositionOfLower(bounds)
+	for i := n.getPositionAfterUpper(bounds) - 1; i >= from; i-- {
+		if !fn(n.data[i]) {
+			return false
+		}
 	}
-	ok, _ = n.CheckKeyStrictlyGreaterThanMax(fromKey)
-	if ok {
-		return
+	return true
+}
+
+// getPositionOfLower returns the position of the first data satisfying bounds.Lower
+func (n *Node[k, v]) getPositionOfLower(bounds Bounds[k]) int {
+	switch bounds.Lower.Type {
+	case INCLUSIVE:
+		return n.getPositionGreaterThanEqualBinary(bounds.Lower.Key)
+	case EXCLUSIVE:
+		return n.getPositionStrictlyGreaterThan(bounds.Lower.Key)
 	}
+	return 0
+}
 
-	fromIdx := n.GetPositionGreaterThanEqual(fromKey)
-	toIdx := n.GetPositionLessThanEqual(toKey)
+// getPositionAfterUpper returns the position right after the last data satisfying bounds.Upper
+func (n *Node[k, v]) getPositionAfterUpper(bounds Bounds[k]) int {
+	switch bounds.Upper.Type {
+	case INCLUSIVE:
+		return n.getPositionStrictlyGreaterThan(bounds.Upper.Key)
+	case EXCLUSIVE:
+		return n.getPositionGreaterThanEqualBinary(bounds.Upper.Key)
+	}
+	return n.dataCount
+}
 
-	if fromIdx == -1 {
-		fromIdx = 0
+// getPositionGreaterThanEqualBinary returns the position of the first data at least `key`,
+// or dataCount if there is none
+func (n *Node[k, v]) getPositionGreaterThanEqualBinary(key k) int {
+	idx := n.GetPositionLessThanEqual(key)
+	if idx == -1 {
+		return 0
 	}
-	if toIdx == -1 {
-		toIdx = n.GetCount()
+	return idx
+}
+
+// getPositionStrictlyGreaterThan returns the position of the first data bigger than `key`,
+// or dataCount if there is none
+func (n *Node[k, v]) getPositionStrictlyGreaterThan(key k) int {
+	idx := n.getPositionGreaterThanEqualBinary(key)
+	if idx < n.dataCount && n.cmp(n.data[idx].Key, key) == 0 {
+		idx++
 	}
-	for i := fromIdx; i < toIdx; i++ {
-		fn(n.data[i])
+	return idx
+}
+
+// alwaysContinue adapts a plain scan callback into one that never stops the scan
+func alwaysContinue[k comparable, v any](fn func(Item[k, v])) func(Item[k, v]) bool {
+	return func(ih Item[k, v]) bool {
+		fn(ih)
+		return true
 	}
 }
 
-// SplitIntoNewNode split current node's contents with the first half still in current node
-// and second half into returned node (may be empty)
+// SplitIntoNewNode split current node's contents with the first `splitRatio` portion still in current node
+// and the rest into returned node (may be empty), whose low key is its first key
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
 func (n *Node[k, v]) SplitIntoNewNode(h int) *Node[k, v] {
-	posToSplit := n.dataCount / 2
-	newNode := NewNodeWithOrderedSlice(h, n.data[posToSplit:], n.dataCount-posToSplit, n.cmp)
+	posToSplit := splitPosition(n.dataCount, n.splitRatio)
+	newNode := newNode[k, v](h, len(n.data), n.splitRatio, n.cmp)
+	newNode.snapshots = n.snapshots
+	if n.snapshots != nil {
+		newNode.ownedEpoch = n.snapshots.epoch.Load()
+	}
+	newNode.versions = n.versions
+	copy(newNode.data, n.data[posToSplit:n.dataCount])
+	newNode.dataCount = n.dataCount - posToSplit
+	if newNode.dataCount > 0 {
+		newNode.lowKey = newNode.data[0].Key
+		newNode.hasLowKey = true
+	}
 	n.dataCount = posToSplit
+	if newNode.hasLowKey {
+		n.history, newNode.history = n.splitHistory(newNode.lowKey)
+	}
 	return newNode
 }
 
+// own makes sure data is not shared with a live Snapshot, before it is changed in place.
+// If it may be, data is copied first, once per Snapshot taken
+//
+// Should only be called when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) own() {
+	if n.snapshots == nil || n.snapshots.live.Load() == 0 {
+		return
+	}
+	epoch := n.snapshots.epoch.Load()
+	if n.ownedEpoch >= epoch {
+		return
+	}
+	data := make([]Item[k, v], len(n.data))
+	copy(data, n.data[:n.dataCount])
+	n.data = data
+	n.ownedEpoch = epoch
+}
+
+// splitPosition returns where `count` data should be split following `ratio`.
+// Both sides keep at least 1 data, whatever the ratio is
+func splitPosition(count int, ratio float64) int {
+	if count < 2 {
+		return count / 2
+	}
+	return min(max(int(float64(count)*ratio), 1), count-1)
+}
+
 // GetMinKey returns the key at pos 0, if any
 //
 // Should only be called either when Lock is held, or when no concurrency is guaranteed
@@ -392,3 +563,13 @@ func (n *Node[k, v]) GetMinKey(notFoundDefaultValue k) (k, error) {
 	}
 	return n.data[0].Key, nil
 }
+
+// GetMaxKey returns the key at the last position, if any
+//
+// Should only be called either when Lock is held, or when no concurrency is guaranteed
+func (n *Node[k, v]) GetMaxKey(notFoundDefaultValue k) (k, error) {
+	if n.dataCount == 0 {
+		return notFoundDefaultValue, ErrNodeIsEmpty
+	}
+	return n.data[n.dataCount-1].Key, nil
+}
@@ -299,3 +299,103 @@ func TestBOWLNodeScan(t *testing.T) {
 		t.Fatalf("It should be 105, but instead we got %d", scanStrictLtSum)
 	}
 }
+
+func TestBOWLNodeScanBounds(t *testing.T) {
+	bn := NewEmptyNode[int, int](16, cmpTest)
+	for i := 1; i <= 30; i++ {
+		bn.Insert(Item[int, int]{Key: i * 2, Value: i * 2})
+	}
+
+	count := 0
+	finished := bn.ScanBoundsWhile(Bounds[int]{}, func(ih Item[int, int]) bool {
+		count++
+		return ih.Key < 20
+	})
+	if finished || count != 10 {
+		t.Fatalf("It should stop at key 20 and report so, but instead we got finished: %v and count: %d", finished, count)
+	}
+	count = 0
+	finished = bn.ReverseScanBoundsWhile(Bounds[int]{}, func(ih Item[int, int]) bool {
+		count++
+		return ih.Key > 50
+	})
+	if finished || count != 6 {
+		t.Fatalf("It should stop at key 50 and report so, but instead we got finished: %v and count: %d", finished, count)
+	}
+
+	// every combination of bound types, with keys around both edges of the node
+	keys := []int{0, 1, 2, 3, 31, 59, 60, 61, 62}
+	boundTypes := []BoundType{UNBOUNDED, INCLUSIVE, EXCLUSIVE}
+	for _, lowerType := range boundTypes {
+		for _, upperType := range boundTypes {
+			for _, lowerKey := range keys {
+				for _, upperKey := range keys {
+					bounds := Bounds[int]{
+						Lower: Bound[int]{Type: lowerType, Key: lowerKey},
+						Upper: Bound[int]{Type: upperType, Key: upperKey},
+					}
+					expected := 0
+					for i := 1; i <= 30; i++ {
+						if bounds.satisfiesLower(cmpTest, i*2) && bounds.satisfiesUpper(cmpTest, i*2) {
+							expected++
+						}
+					}
+					count, prev := 0, 0
+					bn.ScanBoundsWhile(bounds, func(ih Item[int, int]) bool {
+						if ih.Key <= prev {
+							t.Fatalf("%v: It should be ascending, but we got %d after %d", bounds, ih.Key, prev)
+						}
+						prev = ih.Key
+						count++
+						return true
+					})
+					if count != expected {
+						t.Fatalf("%v: It should scan %d data, but instead we got %d", bounds, expected, count)
+					}
+					count, prev = 0, 100
+					bn.ReverseScanBoundsWhile(bounds, func(ih Item[int, int]) bool {
+						if ih.Key >= prev {
+							t.Fatalf("%v: It should be descending, but we got %d after %d", bounds, ih.Key, prev)
+						}
+						prev = ih.Key
+						count++
+						return true
+					})
+					if count != expected {
+						t.Fatalf("%v: It should reverse scan %d data, but instead we got %d", bounds, expected, count)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBOWLNodeUpsert(t *testing.T) {
+	bn := NewEmptyNode[int, int](4, cmpTest)
+	for i := 0; i < NODE_SIZE-1; i++ {
+		bn.Insert(Item[int, int]{Key: i * 2, Value: i})
+	}
+
+	result, err := bn.Upsert(Item[int, int]{Key: 10, Value: 100})
+	if err != nil || result != UPSERT_REPLACED {
+		t.Fatalf("Upserting existing key 10 should replace it, but instead we got %v and %v", result, err)
+	}
+	val, _ := bn.Get(10, math.MinInt)
+	if val != 100 {
+		t.Fatalf("Value of key 10 should be 100 after upsert, but instead we got %d", val)
+	}
+
+	result, err = bn.Upsert(Item[int, int]{Key: 11, Value: 11})
+	if err != nil || result != UPSERT_CREATED {
+		t.Fatalf("Upserting new key 11 should create it, but instead we got %v and %v", result, err)
+	}
+
+	_, err = bn.Upsert(Item[int, int]{Key: 13, Value: 13})
+	if err == nil || err != ErrNodeIsFull {
+		t.Fatalf("Upserting new key into full node should return ErrNodeIsFull, but instead we got %v", err)
+	}
+	result, err = bn.Upsert(Item[int, int]{Key: 12, Value: 120})
+	if err != nil || result != UPSERT_REPLACED {
+		t.Fatalf("Upserting existing key into full node should still replace it, but instead we got %v and %v", result, err)
+	}
+}
@@ -0,0 +1,159 @@
+package bowl
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Option configures a Bowl created by NewBOWL
+type Option func(*options)
+
+type options struct {
+	strictValidation bool
+	nodeSize         int
+	maxHeight        int
+	levelProbability float64
+	splitRatio       float64
+	levelGenerator   LevelGenerator
+	seed             int64
+	seeded           bool
+	lockingMode      LockingMode
+	mvcc             bool
+	syncPolicy       SyncPolicy
+	syncInterval     time.Duration
+}
+
+func defaultOptions() options {
+	return options{
+		strictValidation: false,
+		nodeSize:         NODE_SIZE,
+		maxHeight:        MAX_HEIGHT,
+		levelProbability: LEVEL_PROBABILITY,
+		splitRatio:       SPLIT_RATIO,
+		lockingMode:      LOCKING_GLOBAL,
+		syncPolicy:       SYNC_ALWAYS,
+	}
+}
+
+// getLevelGenerator returns the injected LevelGenerator,
+// or a new one with the given (or random) seed and level probability
+func (o options) getLevelGenerator() LevelGenerator {
+	if o.levelGenerator != nil {
+		return o.levelGenerator
+	}
+	seed := o.seed
+	if !o.seeded {
+		seed = rand.Int63()
+	}
+	return NewRandomLevelGenerator(seed, o.levelProbability)
+}
+
+// WithStrictValidation makes Get, Insert, Update, and Delete check that the batch
+// is ascending-sorted without duplicate keys, before taking the lock.
+// Invalid batches are rejected as a whole with ErrUnsortedBatch or ErrDuplicateKeyInBatch,
+// instead of giving undefined results. Get has no error to return, so use TryGet to get it
+func WithStrictValidation() Option {
+	return func(o *options) {
+		o.strictValidation = true
+	}
+}
+
+// WithNodeSize sets how many data a single node can hold before it is split.
+// Small nodes make writes cheaper, big ones make scans faster and use less memory per data.
+// Defaults to NODE_SIZE, and panics if `n` is less than 2
+func WithNodeSize(n int) Option {
+	if n < 2 {
+		panic(fmt.Sprintf("Node size should be at least 2, but got %d", n))
+	}
+	return func(o *options) {
+		o.nodeSize = n
+	}
+}
+
+// WithMaxHeight sets the highest level a node can reach.
+// Defaults to MAX_HEIGHT, and panics if `h` is less than 1
+func WithMaxHeight(h int) Option {
+	if h < 1 {
+		panic(fmt.Sprintf("Max height should be at least 1, but got %d", h))
+	}
+	return func(o *options) {
+		o.maxHeight = h
+	}
+}
+
+// WithLevelProbability sets the probability of a new node getting one more level.
+// Ignored if WithLevelGenerator is given. Defaults to LEVEL_PROBABILITY, and panics if `p` is not between 0 and 1 (exclusive)
+func WithLevelProbability(p float64) Option {
+	if p <= 0 || p >= 1 {
+		panic(fmt.Sprintf("Level probability should be between 0 and 1, but got %v", p))
+	}
+	return func(o *options) {
+		o.levelProbability = p
+	}
+}
+
+// WithSplitRatio sets the portion of data staying in the old node when a full node is split.
+// A higher ratio suits ascending inserts, as the new node gets more free space.
+// Defaults to SPLIT_RATIO, and panics if `r` is not between 0 and 1 (exclusive)
+func WithSplitRatio(r float64) Option {
+	if r <= 0 || r >= 1 {
+		panic(fmt.Sprintf("Split ratio should be between 0 and 1, but got %v", r))
+	}
+	return func(o *options) {
+		o.splitRatio = r
+	}
+}
+
+// WithSeed makes the default LevelGenerator start from `seed`,
+// so the same inserts always build the same layout. Ignored if WithLevelGenerator is given
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
+
+// WithLevelGenerator replaces the default LevelGenerator with `g`.
+// `g` should not be shared with other Bowl, unless it is goroutine-safe
+func WithLevelGenerator(g LevelGenerator) Option {
+	if g == nil {
+		panic("Level generator should not be nil")
+	}
+	return func(o *options) {
+		o.levelGenerator = g
+	}
+}
+
+// WithMVCC makes the Bowl keep the older versions of its data, replaced or deleted,
+// so they can be read with GetAsOf and the ...AsOf scans until dropped with CollectGarbage.
+// Without it, only the latest versions are readable.
+//
+// Note that a failed atomic write is rolled back with new writes,
+// so reads as of the sequences in between see its partial result
+func WithMVCC() Option {
+	return func(o *options) {
+		o.mvcc = true
+	}
+}
+
+// WithLockingMode sets how the Bowl synchronizes its operations.
+// Defaults to LOCKING_GLOBAL, see LockingMode for the guarantee of each
+func WithLockingMode(mode LockingMode) Option {
+	return func(o *options) {
+		o.lockingMode = mode
+	}
+}
+
+// WithSyncPolicy sets when the write-ahead log of OpenBOWL is synced to disk, see SyncPolicy.
+// `interval` is only used with SYNC_INTERVAL, and should be positive then, or else it panics.
+// Ignored by NewBOWL
+func WithSyncPolicy(policy SyncPolicy, interval time.Duration) Option {
+	if policy == SYNC_INTERVAL && interval <= 0 {
+		panic(fmt.Sprintf("Sync interval should be positive, but got %v", interval))
+	}
+	return func(o *options) {
+		o.syncPolicy = policy
+		o.syncInterval = interval
+	}
+}
@@ -0,0 +1,123 @@
+package bowl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestBowlLayoutOptions(t *testing.T) {
+	layouts := []struct {
+		nodeSize   int
+		maxHeight  int
+		p          float64
+		splitRatio float64
+	}{
+		{2, 1, 0.5, 0.5},
+		{3, 4, 0.25, 0.1},
+		{16, 8, 0.5, 0.9},
+		{1024, 32, 0.75, 0.5},
+	}
+	for _, layout := range layouts {
+		name := fmt.Sprintf("size=%d,height=%d,p=%v,split=%v",
+			layout.nodeSize, layout.maxHeight, layout.p, layout.splitRatio)
+		b := NewBOWL[int, int](cmpTest,
+			WithNodeSize(layout.nodeSize),
+			WithMaxHeight(layout.maxHeight),
+			WithLevelProbability(layout.p),
+			WithSplitRatio(layout.splitRatio))
+
+		rnd := rand.New(rand.NewSource(42))
+		model := make(map[int]bool)
+		for i := 0; i < 20; i++ {
+			ihs := make([]Item[int, int], 0, 200)
+			for j := rnd.Intn(50); j < 5000; j += rnd.Intn(50) + 1 {
+				ihs = append(ihs, Item[int, int]{Key: j, Value: j})
+				model[j] = true
+			}
+			b.Upsert(ihs)
+		}
+
+		expected := make([]Item[int, int], 0, len(model))
+		for i := 0; i < 5000; i++ {
+			if model[i] {
+				expected = append(expected, Item[int, int]{Key: i, Value: i})
+			}
+		}
+		checkSameContent(t, name, expected, snapshotContent(b))
+		checkBowlLinks(t, b)
+
+		if len(b.head.nextNodes) != layout.maxHeight {
+			t.Fatalf("%s: head should have height %d, but instead we got %d", name, layout.maxHeight, len(b.head.nextNodes))
+		}
+		node, _ := b.head.GetNextNodeAt(0)
+		for node != nil {
+			if len(node.data) != layout.nodeSize {
+				t.Fatalf("%s: node should hold %d data, but instead it holds %d", name, layout.nodeSize, len(node.data))
+			}
+			if node.GetHeight() > layout.maxHeight {
+				t.Fatalf("%s: node height should be at most %d, but instead we got %d", name, layout.maxHeight, node.GetHeight())
+			}
+			node, _ = node.GetNextNodeAt(0)
+		}
+	}
+}
+
+func TestBowlSplitRatio(t *testing.T) {
+	// ascending inserts only ever fill the last node,
+	// so a high split ratio should leave the earlier nodes almost full
+	b := NewBOWL[int, int](cmpTest, WithNodeSize(100), WithSplitRatio(0.9))
+	for i := 0; i < 100; i++ {
+		ihs := make([]Item[int, int], 0, 10)
+		for j := i * 10; j < (i+1)*10; j++ {
+			ihs = append(ihs, Item[int, int]{Key: j, Value: j})
+		}
+		b.Insert(ihs)
+	}
+
+	node, _ := b.head.GetNextNodeAt(0)
+	for node != nil {
+		next, _ := node.GetNextNodeAt(0)
+		if next != nil && node.dataCount != 90 {
+			t.Fatalf("Every node but the last should hold 90 data, but instead we got %d", node.dataCount)
+		}
+		node = next
+	}
+}
+
+func TestBowlInvalidOptions(t *testing.T) {
+	invalids := map[string]func(){
+		"WithNodeSize(1)":            func() { WithNodeSize(1) },
+		"WithMaxHeight(0)":           func() { WithMaxHeight(0) },
+		"WithLevelProbability(0)":    func() { WithLevelProbability(0) },
+		"WithLevelProbability(1)":    func() { WithLevelProbability(1) },
+		"WithSplitRatio(0)":          func() { WithSplitRatio(0) },
+		"WithSplitRatio(1.5)":        func() { WithSplitRatio(1.5) },
+		"WithLevelProbability(-0.5)": func() { WithLevelProbability(-0.5) },
+	}
+	for name, fn := range invalids {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("%s should panic, but it does not", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// BenchmarkBowlNodeSize shows the trade-off of node size, where
+// bigger nodes cost more on each insert (shifting data), but need less nodes and pointers
+func BenchmarkBowlNodeSize(b *testing.B) {
+	for _, size := range []int{64, 256, 1024, 4096} {
+		b.Run(fmt.Sprintf("Write/size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			benchmarkBowlWrite(b, WithNodeSize(size))
+		})
+		b.Run(fmt.Sprintf("Read/size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			benchmarkBowlRead(b, WithNodeSize(size))
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package bowl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// SHARD_SKEW_FACTOR is how many times bigger than the even share a shard can get
+// before the shards are rebalanced
+const SHARD_SKEW_FACTOR float64 = 1.5
+
+// ShardedBowl partitions the key space into ranges, each backed by its own Bowl,
+// so writers on different ranges do not wait for each other.
+//
+// Each batch is split at the shard boundaries, and the parts are applied to their shards in parallel.
+// Each part is as isolated as its Bowl makes it, but a batch crossing shards is NOT isolated as a whole.
+// Likewise, a scan goes through the shards in order, seeing each one as of the time it gets there.
+//
+// The shards are rebalanced when one gets SHARD_SKEW_FACTOR times bigger than the even share,
+// by splitting it at its median, and merging the smallest neighbouring shards back,
+// so the number of shards stays around what it is created with
+type ShardedBowl[k comparable, v any] struct {
+	// RWMutex guards the shard map. Operations share it, only rebalancing holds it
+	sync.RWMutex
+	cmp Comparator[k]
+
+	// shards[i] has keys from boundaries[i-1] (inclusive) until boundaries[i] (exclusive)
+	shards     []*shard[k, v]
+	boundaries []k
+
+	targetShards     int
+	rebalancing      atomic.Bool
+	strictValidation bool
+	opts             []Option
+}
+
+type shard[k comparable, v any] struct {
+	bowl *Bowl[k, v]
+	// size follows the number of data in bowl, to detect skew without scanning it
+	size atomic.Int64
+}
+
+// NewShardedBOWL creates our new empty ShardedBowl, with len(boundaries)+1 shards.
+// boundaries should be ascending-sorted, and are only where the shards start,
+// they move as the shards get rebalanced. opts are applied to every shard
+func NewShardedBOWL[k comparable, v any](
+	cmp Comparator[k], boundaries []k, opts ...Option) *ShardedBowl[k, v] {
+	if err := validateSortedBatch(len(boundaries), func(i int) k { return boundaries[i] }, cmp); err != nil {
+		panic(fmt.Sprintf("Boundaries should be ascending-sorted, but %v", err))
+	}
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	b := &ShardedBowl[k, v]{
+		cmp:              cmp,
+		shards:           make([]*shard[k, v], len(boundaries)+1),
+		boundaries:       append([]k(nil), boundaries...),
+		targetShards:     len(boundaries) + 1,
+		strictValidation: o.strictValidation,
+		opts:             opts,
+	}
+	for i := range b.shards {
+		b.shards[i] = b.newShard()
+	}
+	return b
+}
+
+func (b *ShardedBowl[k, v]) newShard() *shard[k, v] {
+	return &shard[k, v]{bowl: NewBOWL[k, v](b.cmp, b.opts...)}
+}
+
+// ShardCount returns the current number of shards
+func (b *ShardedBowl[k, v]) ShardCount() int {
+	b.RLock()
+	defer b.RUnlock()
+	return len(b.shards)
+}
+
+// Len returns the number of data in this ShardedBowl
+func (b *ShardedBowl[k, v]) Len() int {
+	b.RLock()
+	defer b.RUnlock()
+	count := 0
+	for _, s := range b.shards {
+		count += int(s.size.Load())
+	}
+	return count
+}
+
+// shardIndexFor returns the index of the shard that should has key
+//
+// Should only be called when the shard map is locked
+func (b *ShardedBowl[k, v]) shardIndexFor(key k) int {
+	return sort.Search(len(b.boundaries), func(i int) bool {
+		return b.cmp(key, b.boundaries[i]) == -1
+	})
+}
+
+// forEachShard splits the n sorted keys returned by keyAt at the shard boundaries,
+// and calls fn for every part, in parallel, with the part being [from, to)
+//
+// Should only be called when the shard map is locked
+func (b *ShardedBowl[k, v]) forEachShard(
+	n int, keyAt func(int) k, fn func(s *shard[k, v], from, to int)) {
+	var wg sync.WaitGroup
+	for from := 0; from < n; {
+		i := b.shardIndexFor(keyAt(from))
+		to := n
+		if i < len(b.boundaries) {
+			to = from + sort.Search(n-from, func(j int) bool {
+				return b.cmp(keyAt(from+j), b.boundaries[i]) != -1
+			})
+		}
+		if from == 0 && to == n {
+			// only a single shard, no need for another goroutine
+			fn(b.shards[i], from, to)
+			return
+		}
+
+		wg.Add(1)
+		go func(s *shard[k, v], from, to int) {
+			defer wg.Done()
+			fn(s, from, to)
+		}(b.shards[i], from, to)
+		from = to
+	}
+	wg.Wait()
+}
+
+// countNil returns how many errs are nil
+func countNil(errs []error) int64 {
+	count := int64(0)
+	for _, err := range errs {
+		if err == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Get returns all values for the given keys
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed.
+// With WithStrictValidation, an invalid batch gets notFoundDefaultValue for every key,
+// use TryGet to get the validation error as well
+func (b *ShardedBowl[k, v]) Get(keys []k, notFoundDefaultValue v) []v {
+	result, err := b.TryGet(keys, notFoundDefaultValue)
+	if err != nil {
+		return fillValues(make([]v, len(keys)), notFoundDefaultValue)
+	}
+	return result
+}
+
+// TryGet returns all values for the given keys,
+// or the validation error when WithStrictValidation is used and the batch is invalid
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *ShardedBowl[k, v]) TryGet(keys []k, notFoundDefaultValue v) ([]v, error) {
+	if err := b.validateKeys(keys); err != nil {
+		return nil, err
+	}
+	return b.get(keys, notFoundDefaultValue), nil
+}
+
+// get returns all values for the given keys, without any validation
+func (b *ShardedBowl[k, v]) get(keys []k, notFoundDefaultValue v) []v {
+	result := make([]v, len(keys))
+
+	b.RLock()
+	defer b.RUnlock()
+	b.forEachShard(len(keys), func(i int) k { return keys[i] }, func(s *shard[k, v], from, to int) {
+		copy(result[from:to], s.bowl.Get(keys[from:to], notFoundDefaultValue))
+	})
+	return result
+}
+
+// Insert inserts all items, failing those whose key already exists
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *ShardedBowl[k, v]) Insert(ihs []Item[k, v]) []error {
+	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err)
+	}
+
+	b.RLock()
+	b.forEachShard(len(ihs), func(i int) k { return ihs[i].Key }, func(s *shard[k, v], from, to int) {
+		copy(errs[from:to], s.bowl.Insert(ihs[from:to]))
+		s.size.Add(countNil(errs[from:to]))
+	})
+	b.RUnlock()
+
+	b.rebalanceIfSkewed()
+	return errs
+}
+
+// Update updates ih[i].Value when mathing ih[i].Key found
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *ShardedBowl[k, v]) Update(ihs []Item[k, v]) []error {
+	errs := make([]error, len(ihs))
+	if err := b.validateItems(ihs); err != nil {
+		return fillErrors(errs, err)
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+	b.forEachShard(len(ihs), func(i int) k { return ihs[i].Key }, func(s *shard[k, v], from, to int) {
+		copy(errs[from:to], s.bowl.Update(ihs[from:to]))
+	})
+	return errs
+}
+
+// Upsert inserts each item, or replaces the value when the key already exists.
+// The result tells which one happened for each item
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *ShardedBowl[k, v]) Upsert(ihs []Item[k, v]) []UpsertResult {
+	results := make([]UpsertResult, len(ihs))
+
+	b.RLock()
+	b.forEachShard(len(ihs), func(i int) k { return ihs[i].Key }, func(s *shard[k, v], from, to int) {
+		created := int64(0)
+		for i, result := range s.bowl.Upsert(ihs[from:to]) {
+			results[from+i] = result
+			if result == UPSERT_CREATED {
+				created++
+			}
+		}
+		s.size.Add(created)
+	})
+	b.RUnlock()
+
+	b.rebalanceIfSkewed()
+	return results
+}
+
+// Delete removes all matching keys
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed
+func (b *ShardedBowl[k, v]) Delete(keys []k) []error {
+	errs := make([]error, len(keys))
+	if err := b.validateKeys(keys); err != nil {
+		return fillErrors(errs, err)
+	}
+
+	b.RLock()
+	b.forEachShard(len(keys), func(i int) k { return keys[i] }, func(s *shard[k, v], from, to int) {
+		copy(errs[from:to], s.bowl.Delete(keys[from:to]))
+		s.size.Add(-countNil(errs[from:to]))
+	})
+	b.RUnlock()
+
+	b.rebalanceIfSkewed()
+	return errs
+}
+
+// ScanAll pass each data to fn
+func (b *ShardedBowl[k, v]) ScanAll(fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanAllWhile pass each data to fn, and stops as soon as fn returns false
+func (b *ShardedBowl[k, v]) ScanAllWhile(fn func(Item[k, v]) bool) {
+	b.ScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ScanGreaterThanEqual pass each data greater than `key` to fn
+func (b *ShardedBowl[k, v]) ScanGreaterThanEqual(key k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanStrictlyLessThan pass each data until `key` to fn
+func (b *ShardedBowl[k, v]) ScanStrictlyLessThan(key k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanRange pass each data between fromKey <= data <= toKey
+func (b *ShardedBowl[k, v]) ScanRange(fromKey k, toKey k, fn func(Item[k, v])) {
+	b.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// shardRange returns the indexes of the first and last shard that may have data inside `bounds`
+//
+// Should only be called when the shard map is locked
+func (b *ShardedBowl[k, v]) shardRange(bounds Bounds[k]) (int, int) {
+	first, last := 0, len(b.shards)-1
+	if bounds.Lower.Type != UNBOUNDED {
+		first = b.shardIndexFor(bounds.Lower.Key)
+	}
+	if bounds.Upper.Type != UNBOUNDED {
+		last = b.shardIndexFor(bounds.Upper.Key)
+	}
+	return first, last
+}
+
+// ScanBoundsWhile pass each data inside `bounds` to fn, going through the shards in order,
+// and stops as soon as fn returns false
+func (b *ShardedBowl[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	b.RLock()
+	defer b.RUnlock()
+
+	cont := true
+	first, last := b.shardRange(bounds)
+	for i := first; i <= last && cont; i++ {
+		b.shards[i].bowl.ScanBoundsWhile(bounds, func(ih Item[k, v]) bool {
+			cont = fn(ih)
+			return cont
+		})
+	}
+}
+
+// ReverseScanAll pass each data to fn, in descending order
+func (b *ShardedBowl[k, v]) ReverseScanAll(fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ReverseScanAllWhile pass each data to fn, in descending order,
+// and stops as soon as fn returns false
+func (b *ShardedBowl[k, v]) ReverseScanAllWhile(fn func(Item[k, v]) bool) {
+	b.ReverseScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ReverseScanGreaterThanEqual pass each data greater than or equal `key` to fn, in descending order
+func (b *ShardedBowl[k, v]) ReverseScanGreaterThanEqual(key k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanStrictlyLessThan pass each data strictly less than `key` to fn, in descending order
+func (b *ShardedBowl[k, v]) ReverseScanStrictlyLessThan(key k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanRange pass each data between fromKey <= data <= toKey, in descending order
+func (b *ShardedBowl[k, v]) ReverseScanRange(fromKey k, toKey k, fn func(Item[k, v])) {
+	b.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ReverseScanBoundsWhile pass each data inside `bounds` to fn, in descending order,
+// going through the shards backward, and stops as soon as fn returns false
+func (b *ShardedBowl[k, v]) ReverseScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	b.RLock()
+	defer b.RUnlock()
+
+	cont := true
+	first, last := b.shardRange(bounds)
+	for i := last; i >= first && cont; i-- {
+		b.shards[i].bowl.ReverseScanBoundsWhile(bounds, func(ih Item[k, v]) bool {
+			cont = fn(ih)
+			return cont
+		})
+	}
+}
+
+// ------------------------------------------------------------------------
+// Rebalancing
+// ------------------------------------------------------------------------
+
+// skewLimit returns the size a shard should not go above, given the total size
+func (b *ShardedBowl[k, v]) skewLimit(total int64) int64 {
+	evenShare := (total + int64(b.targetShards) - 1) / int64(b.targetShards)
+	return int64(float64(evenShare) * SHARD_SKEW_FACTOR)
+}
+
+// isSkewed checks whether any shard is above the skew limit
+//
+// Should only be called when the shard map is locked
+func (b *ShardedBowl[k, v]) isSkewed() bool {
+	total, largest := int64(0), int64(0)
+	for _, s := range b.shards {
+		size := s.size.Load()
+		total += size
+		largest = max(largest, size)
+	}
+	return largest > 1 && largest > b.skewLimit(total)
+}
+
+// rebalanceIfSkewed rebalances the shards when they are skewed.
+// Only one caller does it at a time, the others do not wait for it
+func (b *ShardedBowl[k, v]) rebalanceIfSkewed() {
+	b.RLock()
+	skewed := b.isSkewed()
+	b.RUnlock()
+	if !skewed || !b.rebalancing.CompareAndSwap(false, true) {
+		return
+	}
+	defer b.rebalancing.Store(false)
+	b.Rebalance()
+}
+
+// Rebalance splits the shards above the skew limit at their median,
+// and merges the smallest neighbouring shards while there are more than created with.
+// It holds the whole shard map, so every other operation waits for it
+func (b *ShardedBowl[k, v]) Rebalance() {
+	b.Lock()
+	defer b.Unlock()
+
+	total := int64(0)
+	for _, s := range b.shards {
+		s.size.Store(int64(s.bowl.Len()))
+		total += s.size.Load()
+	}
+	limit := b.skewLimit(total)
+
+	// each step either splits a shard above the limit into 2 below it,
+	// or merges 2 into 1 below it, so this ends. The bound is only a safety net
+	for step := 0; step < 4*b.targetShards*b.targetShards; step++ {
+		changed := false
+
+		largest := 0
+		for i, s := range b.shards {
+			if s.size.Load() > b.shards[largest].size.Load() {
+				largest = i
+			}
+		}
+		if size := b.shards[largest].size.Load(); size > 1 && size > limit {
+			b.splitShard(largest)
+			changed = true
+		}
+
+		if len(b.shards) > b.targetShards {
+			smallest := 0
+			for i := 1; i < len(b.shards)-1; i++ {
+				if b.pairSize(i) < b.pairSize(smallest) {
+					smallest = i
+				}
+			}
+			if b.pairSize(smallest) <= limit {
+				b.mergeShards(smallest)
+				changed = true
+			}
+		}
+
+		if !changed {
+			return
+		}
+	}
+}
+
+// pairSize returns the size of shard i and the one after it
+//
+// Should only be called when the shard map is held
+func (b *ShardedBowl[k, v]) pairSize(i int) int64 {
+	return b.shards[i].size.Load() + b.shards[i+1].size.Load()
+}
+
+// shardItems returns all data of shard i
+//
+// Should only be called when the shard map is held
+func (b *ShardedBowl[k, v]) shardItems(i int) []Item[k, v] {
+	ihs := make([]Item[k, v], 0, b.shards[i].size.Load())
+	b.shards[i].bowl.ScanAll(func(ih Item[k, v]) {
+		ihs = append(ihs, ih)
+	})
+	return ihs
+}
+
+// splitShard moves the upper half of shard i into a new shard right after it
+//
+// Should only be called when the shard map is held
+func (b *ShardedBowl[k, v]) splitShard(i int) {
+	ihs := b.shardItems(i)
+	upper := ihs[len(ihs)/2:]
+	keys := make([]k, len(upper))
+	for j, ih := range upper {
+		keys[j] = ih.Key
+	}
+
+	newShard := b.newShard()
+	newShard.bowl.Insert(upper)
+	newShard.size.Store(int64(len(upper)))
+	b.shards[i].bowl.Delete(keys)
+	b.shards[i].size.Add(-int64(len(upper)))
+
+	b.shards = append(b.shards[:i+1], append([]*shard[k, v]{newShard}, b.shards[i+1:]...)...)
+	b.boundaries = append(b.boundaries[:i], append([]k{upper[0].Key}, b.boundaries[i:]...)...)
+}
+
+// mergeShards moves all data of shard i+1 into shard i, and removes shard i+1
+//
+// Should only be called when the shard map is held
+func (b *ShardedBowl[k, v]) mergeShards(i int) {
+	ihs := b.shardItems(i + 1)
+	b.shards[i].bowl.Insert(ihs)
+	b.shards[i].size.Add(int64(len(ihs)))
+
+	b.shards = append(b.shards[:i+1], b.shards[i+2:]...)
+	b.boundaries = append(b.boundaries[:i], b.boundaries[i+1:]...)
+}
+
+// validateKeys returns the validation error of keys, if strict validation is enabled
+func (b *ShardedBowl[k, v]) validateKeys(keys []k) error {
+	if !b.strictValidation {
+		return nil
+	}
+	return validateSortedBatch(len(keys), func(i int) k { return keys[i] }, b.cmp)
+}
+
+// validateItems returns the validation error of ihs, if strict validation is enabled
+func (b *ShardedBowl[k, v]) validateItems(ihs []Item[k, v]) error {
+	if !b.strictValidation {
+		return nil
+	}
+	return validateSortedBatch(len(ihs), func(i int) k { return ihs[i].Key }, b.cmp)
+}
@@ -0,0 +1,162 @@
+package bowl
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestShardedBowl(t *testing.T) {
+	b := NewShardedBOWL[int, int](cmpTest, []int{500, 1000, 1500}, WithNodeSize(8), WithSeed(42))
+	checkAgainstModel(t, "sharded", b)
+	checkShardBoundaries(t, b)
+}
+
+func TestShardedBowlInvalidBoundaries(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Unsorted boundaries should panic, but it does not")
+		}
+	}()
+	NewShardedBOWL[int, int](cmpTest, []int{10, 5})
+}
+
+func TestShardedBowlRebalance(t *testing.T) {
+	// everything goes into the last shard at first, which should be split,
+	// and the empty shards merged, leaving the shard count where it started
+	b := NewShardedBOWL[int, int](cmpTest, []int{-3000, -2000, -1000})
+	expected := make([]Item[int, int], 0, 10000)
+	for i := 0; i < 10000; i += 100 {
+		ihs := make([]Item[int, int], 0, 100)
+		for j := i; j < i+100; j++ {
+			ihs = append(ihs, Item[int, int]{Key: j, Value: j})
+		}
+		b.Insert(ihs)
+		expected = append(expected, ihs...)
+	}
+
+	if b.ShardCount() != 4 {
+		t.Fatalf("It should still have 4 shards, but instead we got %d", b.ShardCount())
+	}
+	if b.Len() != 10000 {
+		t.Fatalf("It should have 10000 data, but instead we got %d", b.Len())
+	}
+	limit := b.skewLimit(10000)
+	for i, s := range b.shards {
+		if size := int64(s.bowl.Len()); size > limit || size != s.size.Load() {
+			t.Fatalf("Shard %d should have at most %d data, and know it, but instead it has %d and thinks %d",
+				i, limit, size, s.size.Load())
+		}
+	}
+	content := make([]Item[int, int], 0, 10000)
+	b.ScanAll(func(ih Item[int, int]) {
+		content = append(content, ih)
+	})
+	checkSameContent(t, "after rebalancing", expected, content)
+	checkShardBoundaries(t, b)
+}
+
+func TestShardedBowlConcurrentWriters(t *testing.T) {
+	// run with -race, batches cross every shard, while rebalancing moves them
+	b := NewShardedBOWL[int, int](cmpTest, []int{100, 200, 300}, WithNodeSize(16))
+	const writers = 8
+	const perWriter = 2000
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for r := 0; r < 2; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				next := math.MaxInt
+				b.ReverseScanAll(func(ih Item[int, int]) {
+					if ih.Key >= next {
+						t.Errorf("Reverse scan should be descending, but %d came after %d", ih.Key, next)
+					}
+					next = ih.Key
+				})
+			}
+		}()
+	}
+
+	var writersWg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writersWg.Add(1)
+		go func(w int) {
+			defer writersWg.Done()
+			for i := 0; i < perWriter; i += 100 {
+				ihs := make([]Item[int, int], 0, 100)
+				for j := i; j < i+100; j++ {
+					key := j*writers + w
+					ihs = append(ihs, Item[int, int]{Key: key, Value: key})
+				}
+				for _, err := range b.Insert(ihs) {
+					if err != nil {
+						t.Errorf("Insert should succeed, but instead we got %v", err)
+					}
+				}
+			}
+		}(w)
+	}
+	writersWg.Wait()
+	close(done)
+	readers.Wait()
+
+	content := make([]Item[int, int], 0, writers*perWriter)
+	b.ScanAll(func(ih Item[int, int]) {
+		content = append(content, ih)
+	})
+	if len(content) != writers*perWriter || b.Len() != writers*perWriter {
+		t.Fatalf("It should have %d data, but instead we got %d, and Len says %d", writers*perWriter, len(content), b.Len())
+	}
+	for i, ih := range content {
+		if ih.Key != i {
+			t.Fatalf("At iter %d it should be %d, but instead we got %v", i, i, ih)
+		}
+	}
+	checkShardBoundaries(t, b)
+}
+
+// checkShardBoundaries checks every shard only has keys inside its boundaries
+func checkShardBoundaries(t *testing.T, b *ShardedBowl[int, int]) {
+	if len(b.boundaries) != len(b.shards)-1 {
+		t.Fatalf("It should have %d boundaries, but instead we got %d", len(b.shards)-1, len(b.boundaries))
+	}
+	for i, s := range b.shards {
+		s.bowl.ScanAll(func(ih Item[int, int]) {
+			if i > 0 && ih.Key < b.boundaries[i-1] || i < len(b.boundaries) && ih.Key >= b.boundaries[i] {
+				t.Fatalf("Shard %d should not have key %d, but it does", i, ih.Key)
+			}
+		})
+	}
+}
+
+// BenchmarkShardedBowlWriteParallel has every goroutine inserting batches crossing every shard
+func BenchmarkShardedBowlWriteParallel(b *testing.B) {
+	bowl := NewShardedBOWL[int, int](cmpTest, []int{1 << 60, 2 << 60, 3 << 60})
+	var nextRange sync.Mutex
+	rangeStart := 0
+	b.RunParallel(func(pb *testing.PB) {
+		nextRange.Lock()
+		start := rangeStart
+		rangeStart += 1 << 40
+		nextRange.Unlock()
+
+		data := make([]Item[int, int], 1024)
+		for pb.Next() {
+			// a quarter of the batch goes into each shard
+			for j := range data {
+				key := j/256<<60 + start + j%256
+				data[j] = Item[int, int]{Key: key, Value: key}
+			}
+			start += 256
+			bowl.Insert(data)
+		}
+	})
+}
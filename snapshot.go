@@ -0,0 +1,234 @@
+package bowl
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+var ErrSnapshotReleased = errors.New("Snapshot is already released")
+
+// snapshotState is shared by a Bowl and all its nodes.
+// epoch is bumped on every Snapshot taken, and live counts those not released yet
+type snapshotState struct {
+	epoch atomic.Uint64
+	live  atomic.Int64
+}
+
+// Snapshot is a read-only view of a Bowl, pinned to the moment it is taken.
+// Writes done to the Bowl afterwards are not seen by it, and are not blocked by it either.
+//
+// Taking it only holds the Bowl long enough to collect the nodes, without copying their data.
+// Instead, while it is not released, a write copies the data of a node before changing it in place,
+// once per node per Snapshot (copy-on-write). So Release it as soon as it is not needed anymore,
+// then the writes stop copying, and the old data is reclaimed once the Snapshot is dropped.
+//
+// A Snapshot is goroutine-safe, but using it after Release panics with ErrSnapshotReleased
+type Snapshot[k comparable, v any] struct {
+	cmp Comparator[k]
+	// nodes are the data of every node with data, in order, as of the time this Snapshot is taken
+	nodes            [][]Item[k, v]
+	count            int
+	strictValidation bool
+
+	state    *snapshotState
+	released atomic.Bool
+}
+
+// Snapshot returns a read-only view of this Bowl, as of now. It should be released with Release.
+//
+// With LOCKING_PER_NODE, it holds the whole Bowl while collecting the nodes
+func (b *Bowl[k, v]) Snapshot() *Snapshot[k, v] {
+	b.readLock()
+	defer b.readUnlock()
+
+	s := &Snapshot[k, v]{
+		cmp:              b.cmp,
+		nodes:            make([][]Item[k, v], 0),
+		strictValidation: b.strictValidation,
+		state:            b.snapshots,
+	}
+	b.snapshots.live.Add(1)
+	b.snapshots.epoch.Add(1)
+
+	node := b.getValidNodeToStartScan()
+	for node != nil {
+		// capped, so nothing can ever append into the node's data through it
+		s.nodes = append(s.nodes, node.data[:node.dataCount:node.dataCount])
+		s.count += node.dataCount
+		node = b.getNextNodeAtHeightWithData(0, node)
+	}
+	return s
+}
+
+// Release lets the Bowl stop preserving the data of this Snapshot.
+// Calling it more than once is fine
+func (s *Snapshot[k, v]) Release() {
+	if s.released.CompareAndSwap(false, true) {
+		s.state.live.Add(-1)
+	}
+}
+
+func (s *Snapshot[k, v]) checkNotReleased() {
+	if s.released.Load() {
+		panic(ErrSnapshotReleased)
+	}
+}
+
+// Len returns the number of data in this Snapshot
+func (s *Snapshot[k, v]) Len() int {
+	s.checkNotReleased()
+	return s.count
+}
+
+// nodeIndexFor returns the index of the node that should has key, the last one whose first key is not above it.
+// Keys before every node go to the first one
+func (s *Snapshot[k, v]) nodeIndexFor(key k) int {
+	i := sort.Search(len(s.nodes), func(i int) bool {
+		return s.cmp(s.nodes[i][0].Key, key) == 1
+	})
+	return max(i-1, 0)
+}
+
+// positionOf returns the position of the first data in `data` not below key
+func (s *Snapshot[k, v]) positionOf(data []Item[k, v], key k) int {
+	return sort.Search(len(data), func(i int) bool {
+		return s.cmp(data[i].Key, key) != -1
+	})
+}
+
+// Get returns all values for the given keys, as of the time this Snapshot is taken
+//
+// Note that keys should already be ascending-sorted, or else the result is NOT guaranteed.
+// With WithStrictValidation, an invalid batch gets notFoundDefaultValue for every key,
+// use TryGet to get the validation error as well
+func (s *Snapshot[k, v]) Get(keys []k, notFoundDefaultValue v) []v {
+	result, err := s.TryGet(keys, notFoundDefaultValue)
+	if err != nil {
+		return fillValues(make([]v, len(keys)), notFoundDefaultValue)
+	}
+	return result
+}
+
+// TryGet returns all values for the given keys, as of the time this Snapshot is taken,
+// or the validation error when WithStrictValidation is used and the batch is invalid
+func (s *Snapshot[k, v]) TryGet(keys []k, notFoundDefaultValue v) ([]v, error) {
+	s.checkNotReleased()
+	if s.strictValidation {
+		if err := validateSortedBatch(len(keys), func(i int) k { return keys[i] }, s.cmp); err != nil {
+			return nil, err
+		}
+	}
+	return s.get(keys, notFoundDefaultValue), nil
+}
+
+// get returns all values for the given keys, without any validation
+func (s *Snapshot[k, v]) get(keys []k, notFoundDefaultValue v) []v {
+	result := make([]v, len(keys))
+	for i, key := range keys {
+		result[i] = notFoundDefaultValue
+		if len(s.nodes) == 0 {
+			continue
+		}
+		data := s.nodes[s.nodeIndexFor(key)]
+		if pos := s.positionOf(data, key); pos < len(data) && s.cmp(data[pos].Key, key) == 0 {
+			result[i] = data[pos].Value
+		}
+	}
+	return result
+}
+
+// ScanAll pass each data to fn
+func (s *Snapshot[k, v]) ScanAll(fn func(Item[k, v])) {
+	s.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanAllWhile pass each data to fn, and stops as soon as fn returns false
+func (s *Snapshot[k, v]) ScanAllWhile(fn func(Item[k, v]) bool) {
+	s.ScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ScanGreaterThanEqual pass each data greater than `key` to fn
+func (s *Snapshot[k, v]) ScanGreaterThanEqual(key k, fn func(Item[k, v])) {
+	s.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanStrictlyLessThan pass each data until `key` to fn
+func (s *Snapshot[k, v]) ScanStrictlyLessThan(key k, fn func(Item[k, v])) {
+	s.ScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ScanRange pass each data between fromKey <= data <= toKey
+func (s *Snapshot[k, v]) ScanRange(fromKey k, toKey k, fn func(Item[k, v])) {
+	s.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanBoundsWhile pass each data inside `bounds` to fn,
+// and stops as soon as fn returns false
+func (s *Snapshot[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	s.checkNotReleased()
+
+	i, pos := 0, 0
+	if bounds.Lower.Type != UNBOUNDED && len(s.nodes) > 0 {
+		i = s.nodeIndexFor(bounds.Lower.Key)
+		pos = s.positionOf(s.nodes[i], bounds.Lower.Key)
+	}
+	for ; i < len(s.nodes); i, pos = i+1, 0 {
+		for _, ih := range s.nodes[i][pos:] {
+			if !bounds.satisfiesLower(s.cmp, ih.Key) {
+				continue
+			}
+			if !bounds.satisfiesUpper(s.cmp, ih.Key) || !fn(ih) {
+				return
+			}
+		}
+	}
+}
+
+// ReverseScanAll pass each data to fn, in descending order
+func (s *Snapshot[k, v]) ReverseScanAll(fn func(Item[k, v])) {
+	s.ReverseScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ReverseScanAllWhile pass each data to fn, in descending order,
+// and stops as soon as fn returns false
+func (s *Snapshot[k, v]) ReverseScanAllWhile(fn func(Item[k, v]) bool) {
+	s.ReverseScanBoundsWhile(Bounds[k]{}, fn)
+}
+
+// ReverseScanGreaterThanEqual pass each data greater than or equal `key` to fn, in descending order
+func (s *Snapshot[k, v]) ReverseScanGreaterThanEqual(key k, fn func(Item[k, v])) {
+	s.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanStrictlyLessThan pass each data strictly less than `key` to fn, in descending order
+func (s *Snapshot[k, v]) ReverseScanStrictlyLessThan(key k, fn func(Item[k, v])) {
+	s.ReverseScanBoundsWhile(Bounds[k]{Upper: Exclusive(key)}, alwaysContinue(fn))
+}
+
+// ReverseScanRange pass each data between fromKey <= data <= toKey, in descending order
+func (s *Snapshot[k, v]) ReverseScanRange(fromKey k, toKey k, fn func(Item[k, v])) {
+	s.ReverseScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ReverseScanBoundsWhile pass each data inside `bounds` to fn, in descending order,
+// and stops as soon as fn returns false
+func (s *Snapshot[k, v]) ReverseScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) {
+	s.checkNotReleased()
+
+	i := len(s.nodes) - 1
+	if bounds.Upper.Type != UNBOUNDED && len(s.nodes) > 0 {
+		i = s.nodeIndexFor(bounds.Upper.Key)
+	}
+	for ; i >= 0; i-- {
+		data := s.nodes[i]
+		for j := len(data) - 1; j >= 0; j-- {
+			if !bounds.satisfiesUpper(s.cmp, data[j].Key) {
+				continue
+			}
+			if !bounds.satisfiesLower(s.cmp, data[j].Key) || !fn(data[j]) {
+				return
+			}
+		}
+	}
+}
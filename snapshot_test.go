@@ -0,0 +1,146 @@
+package bowl
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// snapshotItems returns all data of s, in order
+func snapshotItems(s *Snapshot[int, int]) []Item[int, int] {
+	result := make([]Item[int, int], 0, s.Len())
+	s.ScanAll(func(ih Item[int, int]) {
+		result = append(result, ih)
+	})
+	return result
+}
+
+func TestSnapshotIsPinned(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(8))
+		before := make([]Item[int, int], 0, 500)
+		for i := 0; i < 1000; i += 2 {
+			before = append(before, Item[int, int]{Key: i, Value: i})
+		}
+		b.Insert(before)
+
+		s := b.Snapshot()
+		// every kind of write, splitting and emptying nodes
+		odd := make([]Item[int, int], 0, 500)
+		for i := 1; i < 1000; i += 2 {
+			odd = append(odd, Item[int, int]{Key: i, Value: i})
+		}
+		b.Insert(odd)
+		b.Update([]Item[int, int]{{Key: 0, Value: -1}, {Key: 500, Value: -1}})
+		b.Upsert([]Item[int, int]{{Key: 2, Value: -1}, {Key: 2000, Value: -1}})
+		b.Delete([]int{4, 6, 8, 10, 12, 14, 16, 18, 20})
+		b.Apply([]int{22}, func(key int, old int, exists bool) (int, Action) { return -1, ACTION_SET })
+		b.CompareAndSwap([]CASItem[int, int]{{Key: 24, Expected: 24, New: -1}}, func(a, b int) bool { return a == b })
+		wb := NewWriteBatch[int, int]()
+		wb.Put(26, -1)
+		wb.Delete(28)
+		b.Write(wb)
+
+		checkSameContent(t, name+" snapshot", before, snapshotItems(s))
+		if s.Len() != len(before) {
+			t.Fatalf("%s: Snapshot should have %d data, but instead we got %d", name, len(before), s.Len())
+		}
+		for i, value := range s.Get([]int{0, 1, 2, 4, 500, 998, 2000}, math.MinInt) {
+			expected := []int{0, math.MinInt, 2, 4, 500, 998, math.MinInt}[i]
+			if value != expected {
+				t.Fatalf("%s: Snapshot Get at %d should be %d, but instead we got %d", name, i, expected, value)
+			}
+		}
+
+		reversed := make([]Item[int, int], 0)
+		s.ReverseScanRange(100, 200, func(ih Item[int, int]) {
+			reversed = append([]Item[int, int]{ih}, reversed...)
+		})
+		checkSameContent(t, name+" snapshot reversed range", before[50:101], reversed)
+		got := make([]Item[int, int], 0)
+		s.ScanRange(101, 199, func(ih Item[int, int]) { got = append(got, ih) })
+		checkSameContent(t, name+" snapshot range", before[51:100], got)
+
+		values := b.Get([]int{0, 1, 4, 22, 24, 26, 28}, math.MinInt)
+		for i, expected := range []int{-1, 1, math.MinInt, -1, -1, -1, math.MinInt} {
+			if values[i] != expected {
+				t.Fatalf("%s: Bowl Get at %d should see the writes, and be %d, but instead we got %d", name, i, expected, values[i])
+			}
+		}
+		s.Release()
+		s.Release()
+		if live := b.snapshots.live.Load(); live != 0 {
+			t.Fatalf("%s: It should have no live snapshot, but instead we got %d", name, live)
+		}
+		checkBowlLinks(t, b)
+	}
+}
+
+func TestSnapshotReleased(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	b.Insert([]Item[int, int]{{Key: 1, Value: 1}})
+	s := b.Snapshot()
+	s.Release()
+
+	// without live snapshots, writes change the data in place again
+	node, _ := b.head.GetNextNodeAt(0)
+	data := node.data
+	b.Update([]Item[int, int]{{Key: 1, Value: 2}})
+	if &node.data[0] != &data[0] {
+		t.Fatalf("Update without live snapshot should not copy the data, but it does")
+	}
+
+	defer func() {
+		if r := recover(); r != ErrSnapshotReleased {
+			t.Fatalf("Using a released Snapshot should panic with ErrSnapshotReleased, but instead we got %v", r)
+		}
+	}()
+	s.ScanAll(func(Item[int, int]) {})
+}
+
+func TestSnapshotWithConcurrentWriters(t *testing.T) {
+	// run with -race, every snapshot should see a whole round, as each round is a single batch.
+	// With LOCKING_PER_NODE a batch is not isolated, so only the data race is checked there
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(16))
+		const keys = 2000
+
+		done := make(chan struct{})
+		var writer sync.WaitGroup
+		writer.Add(1)
+		go func() {
+			defer writer.Done()
+			for round := 1; ; round++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				ihs := make([]Item[int, int], 0, keys)
+				for i := 0; i < keys; i += 1 + round%3 {
+					ihs = append(ihs, Item[int, int]{Key: i, Value: round})
+				}
+				b.Upsert(ihs)
+			}
+		}()
+
+		for i := 0; i < 50; i++ {
+			s := b.Snapshot()
+			round := -1
+			s.ScanAll(func(ih Item[int, int]) {
+				if ih.Key%6 == 0 && round == -1 {
+					round = ih.Value
+				}
+				if ih.Key%6 == 0 && ih.Value != round && mode == LOCKING_GLOBAL {
+					t.Errorf("%s: Snapshot should see a single round on keys all rounds write, but got %d and %d",
+						name, round, ih.Value)
+				}
+			})
+			s.Release()
+			runtime.Gosched()
+		}
+		close(done)
+		writer.Wait()
+	}
+}
@@ -0,0 +1,267 @@
+package bowl
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+var ErrConflict = errors.New("Data read by the transaction is changed by another write")
+var ErrTxnDone = errors.New("Transaction is already committed or rolled back")
+
+// txnRead is a key read by a Txn, with the Seq it had, or exists false if it was not there
+type txnRead[k comparable] struct {
+	key    k
+	seq    uint64
+	exists bool
+}
+
+// txnScan is a range scanned by a Txn, with the key and Seq of every data it went through
+type txnScan[k comparable, v any] struct {
+	bounds Bounds[k]
+	seen   []Item[k, v]
+}
+
+// Txn is an interactive read-write transaction, started by Bowl.Begin.
+// Reads go to the latest data, writes are kept in the Txn until Commit, and are seen by its own reads.
+//
+// It is optimistic: nothing is held while it runs. Commit checks under the whole Bowl that
+// every key read still has the same Seq (or is still missing), and every range scanned still has the same data,
+// then applies all writes in a single ordered pass, just like Bowl.Write.
+// If anything changed, nothing is applied and ErrConflict is returned, so the caller can retry from Begin.
+// This makes committed transactions serializable, also against plain writes outside any Txn.
+//
+// A Txn is not goroutine-safe
+type Txn[k comparable, v any] struct {
+	b *Bowl[k, v]
+
+	reads []txnRead[k]
+	scans []txnScan[k, v]
+	// writes are sorted by key, at most one per key, either OP_PUT or OP_DELETE
+	writes []batchOp[k, v]
+
+	// optimistic records what is read, to be checked on Commit.
+	// Without it, e.g. for LockingTxn, the reads are protected some other way
+	optimistic bool
+	done       bool
+}
+
+// Begin starts a new Txn on this Bowl
+func (b *Bowl[k, v]) Begin() *Txn[k, v] {
+	return &Txn[k, v]{b: b, optimistic: true}
+}
+
+// getItemForRead returns the item of key, if any
+//
+// Should only be called when readLock or Lock is held
+func (b *Bowl[k, v]) getItemForRead(key k) (Item[k, v], bool) {
+	node := b.getNodeForRead(key)
+	if node == nil {
+		return Item[k, v]{}, false
+	}
+	return node.getItem(key)
+}
+
+// findWrite returns the position of key in t.writes, and whether it is there
+func (t *Txn[k, v]) findWrite(key k) (int, bool) {
+	return slices.BinarySearchFunc(t.writes, key, func(op batchOp[k, v], key k) int {
+		return t.b.cmp(op.item.Key, key)
+	})
+}
+
+// Get returns the values of the given keys, which can be in any order.
+// Keys written by this Txn give what is written, the others are read from the Bowl and checked on Commit
+func (t *Txn[k, v]) Get(keys []k, notFoundDefaultValue v) ([]v, error) {
+	if t.done {
+		return nil, ErrTxnDone
+	}
+	result := make([]v, len(keys))
+
+	t.b.readLock()
+	defer t.b.readUnlock()
+	for i, key := range keys {
+		result[i] = notFoundDefaultValue
+		if pos, ok := t.findWrite(key); ok {
+			if t.writes[pos].opType == OP_PUT {
+				result[i] = t.writes[pos].item.Value
+			}
+			continue
+		}
+
+		ih, exists := t.b.getItemForRead(key)
+		if t.optimistic {
+			t.reads = append(t.reads, txnRead[k]{key: key, seq: ih.Seq, exists: exists})
+		}
+		if exists {
+			result[i] = ih.Value
+		}
+	}
+	return result, nil
+}
+
+// ScanAll pass each data to fn, see ScanBoundsWhile
+func (t *Txn[k, v]) ScanAll(fn func(Item[k, v])) error {
+	return t.ScanBoundsWhile(Bounds[k]{}, alwaysContinue(fn))
+}
+
+// ScanRange pass each data between fromKey <= data <= toKey, see ScanBoundsWhile
+func (t *Txn[k, v]) ScanRange(fromKey k, toKey k, fn func(Item[k, v])) error {
+	return t.ScanBoundsWhile(Bounds[k]{Lower: Inclusive(fromKey), Upper: Inclusive(toKey)}, alwaysContinue(fn))
+}
+
+// ScanBoundsWhile pass each data inside `bounds` to fn, and stops as soon as fn returns false.
+// The data written by this Txn is merged in, with Seq 0.
+// On Commit, the range is checked up to where the scan stopped, so no data can appear into it, nor be changed
+func (t *Txn[k, v]) ScanBoundsWhile(bounds Bounds[k], fn func(Item[k, v]) bool) error {
+	if t.done {
+		return ErrTxnDone
+	}
+	cmp := t.b.cmp
+
+	// the writes of this Txn inside bounds
+	start := 0
+	if bounds.Lower.Type != UNBOUNDED {
+		start, _ = t.findWrite(bounds.Lower.Key)
+	}
+	writes := t.writes[start:]
+	for len(writes) > 0 && !bounds.satisfiesLower(cmp, writes[0].item.Key) {
+		writes = writes[1:]
+	}
+
+	scan := txnScan[k, v]{bounds: bounds, seen: make([]Item[k, v], 0)}
+	stopped := false
+	// pass passes ih to fn, and narrows the checked range if fn wants to stop
+	pass := func(ih Item[k, v]) bool {
+		if fn(ih) {
+			return true
+		}
+		stopped = true
+		scan.bounds.Upper = Inclusive(ih.Key)
+		return false
+	}
+	// passWritesBefore passes the puts of this Txn before key, or all of them if !hasKey
+	passWritesBefore := func(key k, hasKey bool) bool {
+		for len(writes) > 0 && (!hasKey || cmp(writes[0].item.Key, key) == -1) {
+			op := writes[0]
+			if !bounds.satisfiesUpper(cmp, op.item.Key) {
+				writes = nil
+				return true
+			}
+			writes = writes[1:]
+			if op.opType == OP_PUT && !pass(op.item) {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.b.ScanBoundsWhile(bounds, func(ih Item[k, v]) bool {
+		if !passWritesBefore(ih.Key, true) {
+			return false
+		}
+		scan.seen = append(scan.seen, Item[k, v]{Key: ih.Key, Seq: ih.Seq})
+		if len(writes) > 0 && cmp(writes[0].item.Key, ih.Key) == 0 {
+			op := writes[0]
+			writes = writes[1:]
+			if op.opType == OP_DELETE {
+				return true
+			}
+			ih = op.item
+		}
+		return pass(ih)
+	})
+	if !stopped {
+		passWritesBefore(scan.bounds.Upper.Key, false)
+	}
+
+	if t.optimistic {
+		t.scans = append(t.scans, scan)
+	}
+	return nil
+}
+
+// Put writes `key` on Commit, inserting or replacing it
+func (t *Txn[k, v]) Put(key k, value v) error {
+	return t.write(OP_PUT, Item[k, v]{Key: key, Value: value})
+}
+
+// Delete deletes `key` on Commit, if it is there by then
+func (t *Txn[k, v]) Delete(key k) error {
+	return t.write(OP_DELETE, Item[k, v]{Key: key})
+}
+
+func (t *Txn[k, v]) write(opType OpType, ih Item[k, v]) error {
+	if t.done {
+		return ErrTxnDone
+	}
+	op := batchOp[k, v]{opType: opType, item: ih}
+	if pos, ok := t.findWrite(ih.Key); ok {
+		t.writes[pos] = op
+	} else {
+		t.writes = slices.Insert(t.writes, pos, op)
+	}
+	return nil
+}
+
+// validate checks that nothing read by this Txn is changed since
+//
+// Should only be called when Lock is held
+func (t *Txn[k, v]) validate() bool {
+	for _, read := range t.reads {
+		ih, exists := t.b.getItemForRead(read.key)
+		if exists != read.exists || ih.Seq != read.seq {
+			return false
+		}
+	}
+
+	for _, scan := range t.scans {
+		i, same := 0, true
+		t.b.scanBounds(context.Background(), scan.bounds, func(ih Item[k, v]) bool {
+			same = i < len(scan.seen) && t.b.cmp(ih.Key, scan.seen[i].Key) == 0 && ih.Seq == scan.seen[i].Seq
+			i++
+			return same
+		})
+		if !same || i != len(scan.seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Commit applies all writes of this Txn at once, or returns ErrConflict and applies nothing
+// if anything it read is changed by another write. Either way, the Txn can not be used anymore
+func (t *Txn[k, v]) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+
+	b := t.b
+	b.Lock()
+	defer b.Unlock()
+	if t.optimistic && !t.validate() {
+		return ErrConflict
+	}
+	if len(t.writes) == 0 {
+		return nil
+	}
+	release, err := b.logOps(WAL_RECORD_BATCH, len(t.writes), func(i int) batchOp[k, v] { return t.writes[i] })
+	if err != nil {
+		return err
+	}
+	defer release()
+	b.structureVersion.Add(1)
+
+	for i := range t.writes {
+		t.writes[i].index = i
+	}
+	// the only possible error is ErrDataNotFound, deleting a key already missing
+	b.applyOps(t.writes, make([]error, len(t.writes)))
+	return nil
+}
+
+// Rollback drops all writes of this Txn. Calling it after Commit, or more than once, is fine
+func (t *Txn[k, v]) Rollback() {
+	t.done = true
+	t.writes = nil
+}
@@ -0,0 +1,183 @@
+package bowl
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+// txnContent returns all data seen by txn, in order
+func txnContent(t *testing.T, txn *Txn[int, int], bounds Bounds[int]) []Item[int, int] {
+	t.Helper()
+	content := make([]Item[int, int], 0)
+	if err := txn.ScanBoundsWhile(bounds, func(ih Item[int, int]) bool {
+		content = append(content, ih)
+		return true
+	}); err != nil {
+		t.Fatalf("Txn scan should succeed, but instead we got %v", err)
+	}
+	return content
+}
+
+func TestTxnCommit(t *testing.T) {
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(4))
+		ihs := make([]Item[int, int], 0, 50)
+		for i := 0; i < 100; i += 2 {
+			ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+		}
+		b.Insert(ihs)
+
+		txn := b.Begin()
+		txn.Put(5, 50)
+		txn.Put(4, 40)
+		txn.Delete(6)
+		txn.Delete(7)
+		txn.Put(200, 2000)
+		values, err := txn.Get([]int{6, 5, 4, 2, 3}, math.MinInt)
+		if err != nil {
+			t.Fatalf("%s: Txn Get should succeed, but instead we got %v", name, err)
+		}
+		for i, expected := range []int{math.MinInt, 50, 40, 2, math.MinInt} {
+			if values[i] != expected {
+				t.Fatalf("%s: Txn Get at %d should see its own writes, and be %d, but instead we got %d",
+					name, i, expected, values[i])
+			}
+		}
+		checkSameContent(t, name+" txn range",
+			[]Item[int, int]{{Key: 2, Value: 2}, {Key: 4, Value: 40}, {Key: 5, Value: 50}, {Key: 8, Value: 8}},
+			txnContent(t, txn, Bounds[int]{Lower: Inclusive(1), Upper: Inclusive(9)}))
+		checkSameContent(t, name+" txn tail",
+			[]Item[int, int]{{Key: 98, Value: 98}, {Key: 200, Value: 2000}},
+			txnContent(t, txn, Bounds[int]{Lower: Exclusive(96)}))
+
+		// nothing is applied before Commit
+		if values := b.Get([]int{4, 5}, math.MinInt); values[0] != 4 || values[1] != math.MinInt {
+			t.Fatalf("%s: Txn writes should not be seen before Commit, but instead we got %v", name, values)
+		}
+		if err := txn.Commit(); err != nil {
+			t.Fatalf("%s: Commit should succeed, but instead we got %v", name, err)
+		}
+		values = b.Get([]int{4, 5, 6, 7, 200}, math.MinInt)
+		for i, expected := range []int{40, 50, math.MinInt, math.MinInt, 2000} {
+			if values[i] != expected {
+				t.Fatalf("%s: Get at %d after Commit should be %d, but instead we got %d", name, i, expected, values[i])
+			}
+		}
+		if err := txn.Commit(); err != ErrTxnDone {
+			t.Fatalf("%s: Second Commit should fail with ErrTxnDone, but instead we got %v", name, err)
+		}
+		if err := txn.Put(1, 1); err != ErrTxnDone {
+			t.Fatalf("%s: Put after Commit should fail with ErrTxnDone, but instead we got %v", name, err)
+		}
+		checkBowlLinks(t, b)
+	}
+}
+
+func TestTxnConflict(t *testing.T) {
+	cases := map[string]struct {
+		read   func(txn *Txn[int, int])
+		other  func(b *Bowl[int, int])
+		expect error
+	}{
+		"updated key": {
+			read:   func(txn *Txn[int, int]) { txn.Get([]int{10}, 0) },
+			other:  func(b *Bowl[int, int]) { b.Update([]Item[int, int]{{Key: 10, Value: 10}}) },
+			expect: ErrConflict,
+		},
+		"deleted and reinserted key": {
+			read: func(txn *Txn[int, int]) { txn.Get([]int{10}, 0) },
+			other: func(b *Bowl[int, int]) {
+				b.Delete([]int{10})
+				b.Insert([]Item[int, int]{{Key: 10, Value: 10}})
+			},
+			expect: ErrConflict,
+		},
+		"missing key inserted": {
+			read:   func(txn *Txn[int, int]) { txn.Get([]int{11}, 0) },
+			other:  func(b *Bowl[int, int]) { b.Insert([]Item[int, int]{{Key: 11, Value: 11}}) },
+			expect: ErrConflict,
+		},
+		"other key updated": {
+			read:   func(txn *Txn[int, int]) { txn.Get([]int{10}, 0) },
+			other:  func(b *Bowl[int, int]) { b.Update([]Item[int, int]{{Key: 12, Value: 12}}) },
+			expect: nil,
+		},
+		"phantom in scanned range": {
+			read:   func(txn *Txn[int, int]) { txn.ScanRange(10, 20, func(Item[int, int]) {}) },
+			other:  func(b *Bowl[int, int]) { b.Insert([]Item[int, int]{{Key: 15, Value: 15}}) },
+			expect: ErrConflict,
+		},
+		"deleted from scanned range": {
+			read:   func(txn *Txn[int, int]) { txn.ScanRange(10, 20, func(Item[int, int]) {}) },
+			other:  func(b *Bowl[int, int]) { b.Delete([]int{20}) },
+			expect: ErrConflict,
+		},
+		"after where the scan stopped": {
+			read: func(txn *Txn[int, int]) {
+				txn.ScanBoundsWhile(Bounds[int]{Lower: Inclusive(10)}, func(ih Item[int, int]) bool { return ih.Key < 14 })
+			},
+			other:  func(b *Bowl[int, int]) { b.Insert([]Item[int, int]{{Key: 15, Value: 15}}) },
+			expect: nil,
+		},
+	}
+
+	for name, mode := range lockingModes {
+		for caseName, c := range cases {
+			b := NewBOWL[int, int](cmpTest, WithLockingMode(mode), WithNodeSize(4))
+			ihs := make([]Item[int, int], 0, 50)
+			for i := 0; i < 100; i += 2 {
+				ihs = append(ihs, Item[int, int]{Key: i, Value: -i})
+			}
+			b.Insert(ihs)
+
+			txn := b.Begin()
+			c.read(txn)
+			txn.Put(1000, 1000)
+			c.other(b)
+			if err := txn.Commit(); err != c.expect {
+				t.Fatalf("%s %s: Commit should return %v, but instead we got %v", name, caseName, c.expect, err)
+			}
+			values := b.Get([]int{1000}, math.MinInt)
+			if (c.expect == nil) != (values[0] == 1000) {
+				t.Fatalf("%s %s: Txn writes should only be applied on successful Commit, but instead we got %d",
+					name, caseName, values[0])
+			}
+		}
+	}
+}
+
+func TestTxnConcurrentIncrements(t *testing.T) {
+	// run with -race, every increment should be kept, as conflicting ones are retried
+	for name, mode := range lockingModes {
+		b := NewBOWL[int, int](cmpTest, WithLockingMode(mode))
+		b.Insert([]Item[int, int]{{Key: 0, Value: 0}, {Key: 1, Value: 0}})
+
+		const writers, increments = 8, 50
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < increments; i++ {
+					for {
+						txn := b.Begin()
+						values, _ := txn.Get([]int{0, 1}, 0)
+						// moving one unit from key 1 to key 0, the sum should stay 0
+						txn.Put(0, values[0]+1)
+						txn.Put(1, values[1]-1)
+						if txn.Commit() == nil {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		values := b.Get([]int{0, 1}, 0)
+		if values[0] != writers*increments || values[1] != -writers*increments {
+			t.Fatalf("%s: Every increment should be kept, but instead we got %v", name, values)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package bowl
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DuplicatePolicy decides what the `...Unsorted` batch operations do,
+// when the same key appears more than once in a batch
+type DuplicatePolicy int
+
+const (
+	// DUPLICATE_LAST_WINS only applies the last occurrence of the key, which gets its result,
+	// while the earlier occurrences get ErrSupersededInBatch, just like in Bowl.Write
+	DUPLICATE_LAST_WINS DuplicatePolicy = 0
+	// DUPLICATE_ERROR applies none of the occurrences of the key,
+	// and every occurrence gets ErrDuplicateKeyInBatch
+	DUPLICATE_ERROR DuplicatePolicy = 1
+)
+
+// ErrDuplicateKeyInBatch means a key appears more than once in a single batch
+type ErrDuplicateKeyInBatch struct {
+	// Index is the position of the first item repeating an earlier key
+	Index int
+}
+
+func (e ErrDuplicateKeyInBatch) Error() string {
+	return fmt.Sprintf("key at index %d already appears earlier in the batch", e.Index)
+}
+
+// groupByKey returns the positions 0..n-1 grouped by equal key,
+// with the groups ascending by key, and each group in its original order
+func groupByKey[k comparable](n int, keyAt func(int) k, cmp Comparator[k]) [][]int {
+	positions := make([]int, n)
+	for i := range positions {
+		positions[i] = i
+	}
+	slices.SortStableFunc(positions, func(a, b int) int {
+		return cmp(keyAt(a), keyAt(b))
+	})
+
+	groups := make([][]int, 0, n)
+	for i, pos := range positions {
+		if i > 0 && cmp(keyAt(pos), keyAt(positions[i-1])) == 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], pos)
+			continue
+		}
+		groups = append(groups, positions[i:i+1:i+1])
+	}
+	return groups
+}
+
+// resolveDuplicates picks the position to apply for each group following policy,
+// and fills errs for every group it rejects.
+// Returns the picked positions, and the groups they came from
+func resolveDuplicates(groups [][]int, policy DuplicatePolicy, errs []error) ([]int, [][]int) {
+	picked := make([]int, 0, len(groups))
+	pickedGroups := make([][]int, 0, len(groups))
+	for _, group := range groups {
+		if len(group) > 1 && policy == DUPLICATE_ERROR {
+			err := ErrDuplicateKeyInBatch{Index: slices.Min(group[1:])}
+			for _, pos := range group {
+				errs[pos] = err
+			}
+			continue
+		}
+		picked = append(picked, group[len(group)-1])
+		pickedGroups = append(pickedGroups, group)
+	}
+	return picked, pickedGroups
+}
+
+// spreadResults gives every position in each group the result of its picked position
+func spreadResults[T any](results []T, pickedGroups [][]int, out []T) {
+	for i, group := range pickedGroups {
+		for _, pos := range group {
+			out[pos] = results[i]
+		}
+	}
+}
+
+// spreadErrors gives the picked position of each group its result,
+// and the other positions ErrSupersededInBatch, as they are never applied
+func spreadErrors(results []error, picked []int, pickedGroups [][]int, out []error) {
+	for i, group := range pickedGroups {
+		for _, pos := range group {
+			out[pos] = ErrSupersededInBatch
+		}
+		out[picked[i]] = results[i]
+	}
+}
+
+// GetUnsorted is Get for keys in any order, and may contain duplicates.
+// The result follows the order of the given keys
+func (b *Bowl[k, v]) GetUnsorted(keys []k, notFoundDefaultValue v) []v {
+	result := make([]v, len(keys))
+	if len(keys) == 0 {
+		return result
+	}
+	groups := groupByKey(len(keys), func(i int) k { return keys[i] }, b.cmp)
+	sorted := make([]k, len(groups))
+	for i, group := range groups {
+		sorted[i] = keys[group[0]]
+	}
+	spreadResults(b.Get(sorted, notFoundDefaultValue), groups, result)
+	return result
+}
+
+// InsertUnsorted is Insert for items in any order.
+// Duplicate keys are resolved following policy,
+// and the returned errors follow the order of the given items
+func (b *Bowl[k, v]) InsertUnsorted(ihs []Item[k, v], policy DuplicatePolicy) []error {
+	return b.writeUnsorted(ihs, policy, b.Insert)
+}
+
+// UpdateUnsorted is Update for items in any order.
+// Duplicate keys are resolved following policy,
+// and the returned errors follow the order of the given items
+func (b *Bowl[k, v]) UpdateUnsorted(ihs []Item[k, v], policy DuplicatePolicy) []error {
+	return b.writeUnsorted(ihs, policy, b.Update)
+}
+
+// DeleteUnsorted is Delete for keys in any order.
+// Duplicate keys are resolved following policy,
+// and the returned errors follow the order of the given keys
+func (b *Bowl[k, v]) DeleteUnsorted(keys []k, policy DuplicatePolicy) []error {
+	errs := make([]error, len(keys))
+	groups := groupByKey(len(keys), func(i int) k { return keys[i] }, b.cmp)
+	picked, pickedGroups := resolveDuplicates(groups, policy, errs)
+	if len(picked) == 0 {
+		return errs
+	}
+	sorted := make([]k, len(picked))
+	for i, pos := range picked {
+		sorted[i] = keys[pos]
+	}
+	spreadErrors(b.Delete(sorted), picked, pickedGroups, errs)
+	return errs
+}
+
+func (b *Bowl[k, v]) writeUnsorted(
+	ihs []Item[k, v], policy DuplicatePolicy, write func([]Item[k, v]) []error) []error {
+	errs := make([]error, len(ihs))
+	groups := groupByKey(len(ihs), func(i int) k { return ihs[i].Key }, b.cmp)
+	picked, pickedGroups := resolveDuplicates(groups, policy, errs)
+	if len(picked) == 0 {
+		return errs
+	}
+	sorted := make([]Item[k, v], len(picked))
+	for i, pos := range picked {
+		sorted[i] = ihs[pos]
+	}
+	spreadErrors(write(sorted), picked, pickedGroups, errs)
+	return errs
+}
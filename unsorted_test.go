@@ -0,0 +1,158 @@
+package bowl
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBowlUnsortedBatch(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	rnd := rand.New(rand.NewSource(11))
+
+	// shuffled, without duplicates
+	perm := rnd.Perm(2000)
+	ihs := make([]Item[int, int], 0, 2000)
+	for _, p := range perm {
+		ihs = append(ihs, Item[int, int]{Key: p, Value: p})
+	}
+	errs := b.InsertUnsorted(ihs, DUPLICATE_ERROR)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Shouldn't return error for iter %d, but instead we got %v", i, err)
+		}
+	}
+	prev := -1
+	b.ScanAll(func(ih Item[int, int]) {
+		if ih.Key != prev+1 {
+			t.Fatalf("Should be ordered without gap, but we got %d after %d", ih.Key, prev)
+		}
+		prev = ih.Key
+	})
+
+	keys := []int{1999, 5, 3000, 5, 0}
+	res := b.GetUnsorted(keys, math.MinInt)
+	expectedValues := []int{1999, 5, math.MinInt, 5, 0}
+	for i := range res {
+		if res[i] != expectedValues[i] {
+			t.Fatalf("GetUnsorted at iter %d should be %d, but instead we got %d", i, expectedValues[i], res[i])
+		}
+	}
+
+	// last-wins applies only the last occurrence, the earlier ones are superseded
+	updates := []Item[int, int]{
+		{Key: 10, Value: 1}, {Key: 5000, Value: 1}, {Key: 3, Value: 1}, {Key: 10, Value: 2}, {Key: 5000, Value: 2},
+	}
+	errs = b.UpdateUnsorted(updates, DUPLICATE_LAST_WINS)
+	if errs[2] != nil || errs[3] != nil {
+		t.Fatalf("Updates of existing keys should succeed, but instead we got %v", errs)
+	}
+	if errs[0] != ErrSupersededInBatch || errs[1] != ErrSupersededInBatch {
+		t.Fatalf("Earlier updates of keys 10 and 5000 should be ErrSupersededInBatch, but instead we got %v and %v",
+			errs[0], errs[1])
+	}
+	if errs[4] != ErrDataNotFound {
+		t.Fatalf("Last update of key 5000 should be ErrDataNotFound, but instead we got %v", errs[4])
+	}
+	res = b.GetUnsorted([]int{10, 3}, math.MinInt)
+	if res[0] != 2 || res[1] != 1 {
+		t.Fatalf("Key 10 should be 2 as last wins, and key 3 should be 1, but instead we got %v", res)
+	}
+
+	// error policy rejects every occurrence, and applies the rest
+	errs = b.DeleteUnsorted([]int{7, 8, 7, 9, 7}, DUPLICATE_ERROR)
+	var dupErr ErrDuplicateKeyInBatch
+	for _, i := range []int{0, 2, 4} {
+		if !errors.As(errs[i], &dupErr) || dupErr.Index != 2 {
+			t.Fatalf("Every occurrence of key 7 should be ErrDuplicateKeyInBatch at index 2, but instead we got %v", errs[i])
+		}
+	}
+	if errs[1] != nil || errs[3] != nil {
+		t.Fatalf("Deleting keys 8 and 9 should succeed, but instead we got %v and %v", errs[1], errs[3])
+	}
+	res = b.GetUnsorted([]int{9, 8, 7}, math.MinInt)
+	if res[0] != math.MinInt || res[1] != math.MinInt || res[2] != 7 {
+		t.Fatalf("Keys 8 and 9 should be deleted, while 7 stays, but instead we got %v", res)
+	}
+
+	if len(b.InsertUnsorted(nil, DUPLICATE_ERROR)) != 0 || len(b.GetUnsorted(nil, 0)) != 0 {
+		t.Fatal("Empty batches should return empty results")
+	}
+}
+
+func TestBowlUnsortedKeyRepeatedThrice(t *testing.T) {
+	// key 5, 1, or 2 is repeated three times, not next to each other
+	dup := func(index int) error { return ErrDuplicateKeyInBatch{Index: index} }
+	cases := map[string]struct {
+		write    func(b *Bowl[int, int]) []error
+		errs     []error
+		expected []Item[int, int]
+	}{
+		"insert last wins": {
+			func(b *Bowl[int, int]) []error {
+				return b.InsertUnsorted([]Item[int, int]{{Key: 5, Value: 1}, {Key: 2, Value: 0}, {Key: 5, Value: 2},
+					{Key: 6, Value: 6}, {Key: 5, Value: 3}}, DUPLICATE_LAST_WINS)
+			},
+			[]error{ErrSupersededInBatch, ErrKeyAlreadyExist, ErrSupersededInBatch, nil, nil},
+			[]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}, {Key: 5, Value: 3}, {Key: 6, Value: 6}},
+		},
+		"insert error": {
+			func(b *Bowl[int, int]) []error {
+				return b.InsertUnsorted([]Item[int, int]{{Key: 5, Value: 1}, {Key: 2, Value: 0}, {Key: 5, Value: 2},
+					{Key: 6, Value: 6}, {Key: 5, Value: 3}}, DUPLICATE_ERROR)
+			},
+			[]error{dup(2), ErrKeyAlreadyExist, dup(2), nil, dup(2)},
+			[]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}, {Key: 6, Value: 6}},
+		},
+		"update last wins": {
+			func(b *Bowl[int, int]) []error {
+				return b.UpdateUnsorted([]Item[int, int]{{Key: 1, Value: 10}, {Key: 7, Value: 0}, {Key: 1, Value: 20},
+					{Key: 1, Value: 30}}, DUPLICATE_LAST_WINS)
+			},
+			[]error{ErrSupersededInBatch, ErrDataNotFound, ErrSupersededInBatch, nil},
+			[]Item[int, int]{{Key: 1, Value: 30}, {Key: 2, Value: 2}},
+		},
+		"update error": {
+			func(b *Bowl[int, int]) []error {
+				return b.UpdateUnsorted([]Item[int, int]{{Key: 1, Value: 10}, {Key: 7, Value: 0}, {Key: 1, Value: 20},
+					{Key: 1, Value: 30}}, DUPLICATE_ERROR)
+			},
+			[]error{dup(2), ErrDataNotFound, dup(2), dup(2)},
+			[]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}},
+		},
+		"delete last wins": {
+			func(b *Bowl[int, int]) []error { return b.DeleteUnsorted([]int{2, 7, 2, 2}, DUPLICATE_LAST_WINS) },
+			[]error{ErrSupersededInBatch, ErrDataNotFound, ErrSupersededInBatch, nil},
+			[]Item[int, int]{{Key: 1, Value: 1}},
+		},
+		"delete error": {
+			func(b *Bowl[int, int]) []error { return b.DeleteUnsorted([]int{2, 1, 2, 2}, DUPLICATE_ERROR) },
+			[]error{dup(2), nil, dup(2), dup(2)},
+			[]Item[int, int]{{Key: 2, Value: 2}},
+		},
+	}
+
+	for name, c := range cases {
+		b := NewBOWL[int, int](cmpTest)
+		b.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}})
+
+		errs := c.write(b)
+		if len(errs) != len(c.errs) {
+			t.Fatalf("%s: There should be one error per item, but instead we got %v", name, errs)
+		}
+		for i := range c.errs {
+			if errs[i] != c.errs[i] {
+				t.Fatalf("%s: Item %d should be %v, but instead we got %v", name, i, c.errs[i], errs[i])
+			}
+		}
+		checkSameContent(t, name, c.expected, snapshotContent(b))
+
+		// reads have nothing to resolve, every occurrence gets the value
+		res := b.GetUnsorted([]int{c.expected[0].Key, 100, c.expected[0].Key, c.expected[0].Key}, math.MinInt)
+		if res[0] != c.expected[0].Value || res[1] != math.MinInt || res[2] != res[0] || res[3] != res[0] {
+			t.Fatalf("%s: Every occurrence of key %d should get %d, but instead we got %v",
+				name, c.expected[0].Key, c.expected[0].Value, res)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package bowl
+
+import (
+	"fmt"
+)
+
+// ErrUnsortedBatch means a batch is not ascending-sorted
+type ErrUnsortedBatch struct {
+	// Index is the position of the first item smaller than the one before it
+	Index int
+}
+
+func (e ErrUnsortedBatch) Error() string {
+	return fmt.Sprintf("key at index %d is smaller than the one before it", e.Index)
+}
+
+// validateSortedBatch checks the n keys returned by keyAt are strictly ascending
+func validateSortedBatch[k comparable](n int, keyAt func(int) k, cmp Comparator[k]) error {
+	for i := 1; i < n; i++ {
+		switch cmp(keyAt(i-1), keyAt(i)) {
+		case 1:
+			return ErrUnsortedBatch{Index: i}
+		case 0:
+			return ErrDuplicateKeyInBatch{Index: i}
+		}
+	}
+	return nil
+}
+
+// validateKeys returns the validation error of keys, if strict validation is enabled
+func (b *Bowl[k, v]) validateKeys(keys []k) error {
+	if !b.strictValidation {
+		return nil
+	}
+	return validateSortedBatch(len(keys), func(i int) k { return keys[i] }, b.cmp)
+}
+
+// validateItems returns the validation error of ihs, if strict validation is enabled
+func (b *Bowl[k, v]) validateItems(ihs []Item[k, v]) error {
+	if !b.strictValidation {
+		return nil
+	}
+	return validateSortedBatch(len(ihs), func(i int) k { return ihs[i].Key }, b.cmp)
+}
+
+// fillErrors sets every position of errs to err
+func fillErrors(errs []error, err error) []error {
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// fillValues sets every position of values to value
+func fillValues[v any](values []v, value v) []v {
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
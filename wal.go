@@ -0,0 +1,406 @@
+package bowl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrWALClosed = errors.New("Write-ahead log is already closed")
+var ErrWALCorrupted = errors.New("Write-ahead log has a corrupted record")
+
+// Codec encodes keys or values of a Bowl into its write-ahead log, and back
+type Codec[T any] interface {
+	// Encode appends the encoded t to dst, returning the extended slice
+	Encode(dst []byte, t T) []byte
+	// Decode decodes what Encode appended, given exactly those bytes
+	Decode(src []byte) (T, error)
+}
+
+// SyncPolicy decides when the write-ahead log is flushed to disk with fsync
+type SyncPolicy int
+
+const (
+	// SYNC_ALWAYS syncs every record before its batch is applied, so nothing acknowledged is ever lost
+	SYNC_ALWAYS SyncPolicy = 0
+	// SYNC_INTERVAL syncs in the background every given interval,
+	// so an OS crash loses at most the batches of the last interval
+	SYNC_INTERVAL SyncPolicy = 1
+	// SYNC_NEVER leaves it to the OS, a process crash loses nothing, but an OS crash may lose anything not flushed yet
+	SYNC_NEVER SyncPolicy = 2
+)
+
+// walRecordKind tells how the ops of a record are replayed
+type walRecordKind byte
+
+const (
+	// WAL_RECORD_BATCH ops are applied one by one, like Bowl.Write
+	WAL_RECORD_BATCH walRecordKind = 1
+	// WAL_RECORD_ATOMIC ops are applied all or nothing, like Bowl.WriteAtomic
+	WAL_RECORD_ATOMIC walRecordKind = 2
+)
+
+// WAL_HEADER_SIZE is the size of the header of each record, the length then the CRC32C of the payload
+const WAL_HEADER_SIZE = 8
+
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// wal is the write-ahead log of a Bowl opened by OpenBOWL.
+//
+// Each record is a little-endian uint32 payload length, a little-endian uint32 CRC32C of the payload,
+// then the payload: the record kind, the uvarint number of ops, and for each op its OpType,
+// the uvarint length and encoded key, and except for OP_DELETE, the uvarint length and encoded value
+type wal[k comparable, v any] struct {
+	keyCodec   Codec[k]
+	valueCodec Codec[v]
+	policy     SyncPolicy
+
+	// mu guards everything below, and is held from appending a record until its batch is applied,
+	// so batches are applied in the order they are logged
+	mu   sync.Mutex
+	file *os.File
+	// buf is the record being appended, scratch is a single key or value being encoded
+	buf     []byte
+	scratch []byte
+	// dirty tells the syncer there is something to sync, with SYNC_INTERVAL
+	dirty bool
+	// err is the first failure of the log. Once failed, nothing is appended anymore
+	err    error
+	closed bool
+
+	stopSyncer chan struct{}
+	syncerDone chan struct{}
+}
+
+// OpenBOWL opens, or creates, a Bowl persisted in the write-ahead log at path.
+// Every write batch is appended to the log before it is applied, with keys and values encoded by the given codecs.
+// The existing log is replayed first, to restore the exact state before it was closed (or crashed),
+// and a torn record at its end, i.e. one cut in the middle or failing its CRC with only zeros after it, is truncated,
+// just like a zero-filled tail. Any other record failing its CRC, or one passing it but failing to decode,
+// fails with ErrWALCorrupted, without touching the log, as the acknowledged records after it would be lost.
+//
+// When to fsync is decided by WithSyncPolicy, defaulting to SYNC_ALWAYS.
+// The returned Bowl should be closed with Close.
+//
+// The log is never compacted, and Snapshot, MVCC history, and sequence numbers are not persisted.
+// A failure of the log is returned by every write, with Upsert giving UPSERT_FAILED instead, see TryUpsert.
+// Either way, nothing of that write is applied, and it is returned by every later write too
+func OpenBOWL[k comparable, v any](
+	path string, cmp Comparator[k], keyCodec Codec[k], valueCodec Codec[v], opts ...Option) (*Bowl[k, v], error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := &wal[k, v]{
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+		policy:     o.syncPolicy,
+		file:       file,
+	}
+
+	b := NewBOWL[k, v](cmp, opts...)
+	if err := w.replay(b); err != nil {
+		file.Close()
+		return nil, err
+	}
+	b.wal = w
+
+	if w.policy == SYNC_INTERVAL {
+		w.stopSyncer = make(chan struct{})
+		w.syncerDone = make(chan struct{})
+		go w.runSyncer(o.syncInterval)
+	}
+	return b, nil
+}
+
+// Close syncs and closes the write-ahead log of this Bowl, returning its first failure, if any.
+// Writes after it fail with ErrWALClosed. Does nothing for a Bowl created by NewBOWL
+func (b *Bowl[k, v]) Close() error {
+	if b.wal == nil {
+		return nil
+	}
+	return b.wal.close()
+}
+
+// logOps appends a record of the n ops given by op, before they are applied.
+// The returned func should be called once they are applied, as the log is held until then
+//
+// Should only be called when writeLock is held
+func (b *Bowl[k, v]) logOps(kind walRecordKind, n int, op func(i int) batchOp[k, v]) (func(), error) {
+	if b.wal == nil || n == 0 {
+		return func() {}, nil
+	}
+	return b.wal.append(kind, n, op)
+}
+
+// append writes a single record, and syncs it with SYNC_ALWAYS.
+// On success, mu is still held, and the returned func releases it
+func (w *wal[k, v]) append(kind walRecordKind, n int, op func(i int) batchOp[k, v]) (func(), error) {
+	w.mu.Lock()
+	if w.err != nil {
+		w.mu.Unlock()
+		return nil, w.err
+	}
+
+	w.buf = append(w.buf[:0], make([]byte, WAL_HEADER_SIZE)...)
+	w.buf = append(w.buf, byte(kind))
+	w.buf = binary.AppendUvarint(w.buf, uint64(n))
+	for i := 0; i < n; i++ {
+		w.buf = w.appendOp(w.buf, op(i))
+	}
+	payload := w.buf[WAL_HEADER_SIZE:]
+	binary.LittleEndian.PutUint32(w.buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(w.buf[4:8], crc32.Checksum(payload, walCRCTable))
+
+	if _, err := w.file.Write(w.buf); err != nil {
+		w.err = err
+	} else if w.policy == SYNC_ALWAYS {
+		w.err = w.file.Sync()
+	}
+	w.dirty = true
+	if w.err != nil {
+		err := w.err
+		w.mu.Unlock()
+		return nil, err
+	}
+	return w.mu.Unlock, nil
+}
+
+func (w *wal[k, v]) appendOp(dst []byte, op batchOp[k, v]) []byte {
+	dst = append(dst, byte(op.opType))
+	w.scratch = w.keyCodec.Encode(w.scratch[:0], op.item.Key)
+	dst = appendWithLength(dst, w.scratch)
+	if op.opType != OP_DELETE {
+		w.scratch = w.valueCodec.Encode(w.scratch[:0], op.item.Value)
+		dst = appendWithLength(dst, w.scratch)
+	}
+	return dst
+}
+
+// appendWithLength appends encoded, prefixed by its uvarint length
+func appendWithLength(dst []byte, encoded []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(encoded)))
+	return append(dst, encoded...)
+}
+
+// replay applies every record of the log into b, then truncates the torn tail, if any.
+// Only a record reaching the end of the log, or followed by zeros only, can be torn
+func (w *wal[k, v]) replay(b *Bowl[k, v]) error {
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	r := bufio.NewReader(w.file)
+	header := make([]byte, WAL_HEADER_SIZE)
+	var payload []byte
+	offset := int64(0)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		length := int64(binary.LittleEndian.Uint32(header[0:4]))
+		end := offset + WAL_HEADER_SIZE + length
+		if end > size {
+			break
+		}
+		payload = slices.Grow(payload[:0], int(length))[:length]
+		// the length is already checked against the size, so anything failing here is not a torn tail
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+		// an all-zero header, e.g. of a zero-filled tail, passes its CRC as an empty record
+		crcMatches := crc32.Checksum(payload, walCRCTable) == binary.LittleEndian.Uint32(header[4:8])
+		if !crcMatches || length == 0 {
+			torn, err := isTornTail(r, end == size)
+			if err != nil {
+				return err
+			}
+			if torn {
+				break
+			}
+			if !crcMatches {
+				return fmt.Errorf("%w: at offset %d, checksum mismatch", ErrWALCorrupted, offset)
+			}
+		}
+
+		kind, ops, err := w.decodeRecord(payload)
+		if err != nil {
+			return fmt.Errorf("%w: at offset %d, %v", ErrWALCorrupted, offset, err)
+		}
+		b.replayRecord(kind, ops)
+		offset += WAL_HEADER_SIZE + length
+	}
+
+	if offset < size {
+		if err := w.file.Truncate(offset); err != nil {
+			return err
+		}
+		if err := w.file.Sync(); err != nil {
+			return err
+		}
+	}
+	_, err = w.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// isTornTail checks whether the record just read is torn, i.e. it is the last one,
+// or everything after it, read from r, is zero
+func isTornTail(r io.Reader, last bool) (bool, error) {
+	if last {
+		return true, nil
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		for _, c := range buf[:n] {
+			if c != 0 {
+				return false, nil
+			}
+		}
+		if err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+}
+
+func (w *wal[k, v]) decodeRecord(payload []byte) (walRecordKind, []batchOp[k, v], error) {
+	if len(payload) == 0 {
+		return 0, nil, errors.New("empty record")
+	}
+	kind := walRecordKind(payload[0])
+	if kind != WAL_RECORD_BATCH && kind != WAL_RECORD_ATOMIC {
+		return 0, nil, fmt.Errorf("unknown record kind %d", kind)
+	}
+	n, read := binary.Uvarint(payload[1:])
+	if read <= 0 {
+		return 0, nil, errors.New("invalid number of ops")
+	}
+	rest := payload[1+read:]
+
+	ops := make([]batchOp[k, v], 0, min(n, uint64(len(rest))))
+	for i := uint64(0); i < n; i++ {
+		if len(rest) == 0 {
+			return 0, nil, errors.New("record ends before its last op")
+		}
+		op := batchOp[k, v]{opType: OpType(rest[0]), index: int(i)}
+		if op.opType < OP_PUT || op.opType > OP_DELETE {
+			return 0, nil, fmt.Errorf("unknown op type %d", op.opType)
+		}
+		var encoded []byte
+		var err error
+		if encoded, rest, err = cutWithLength(rest[1:]); err != nil {
+			return 0, nil, err
+		}
+		if op.item.Key, err = w.keyCodec.Decode(encoded); err != nil {
+			return 0, nil, err
+		}
+		if op.opType != OP_DELETE {
+			if encoded, rest, err = cutWithLength(rest); err != nil {
+				return 0, nil, err
+			}
+			if op.item.Value, err = w.valueCodec.Decode(encoded); err != nil {
+				return 0, nil, err
+			}
+		}
+		ops = append(ops, op)
+	}
+	if len(rest) != 0 {
+		return 0, nil, errors.New("record has trailing bytes")
+	}
+	return kind, ops, nil
+}
+
+// cutWithLength cuts what appendWithLength appended from the start of src
+func cutWithLength(src []byte) ([]byte, []byte, error) {
+	length, read := binary.Uvarint(src)
+	if read <= 0 || uint64(len(src)-read) < length {
+		return nil, nil, errors.New("record ends in the middle of an encoded key or value")
+	}
+	end := read + int(length)
+	return src[read:end], src[end:], nil
+}
+
+// replayRecord applies ops just like the write that logged them
+//
+// Should only be called when no concurrency is guaranteed
+func (b *Bowl[k, v]) replayRecord(kind walRecordKind, ops []batchOp[k, v]) {
+	if len(ops) == 0 {
+		return
+	}
+	// already sorted, unless the logged batch was not
+	slices.SortStableFunc(ops, func(x, y batchOp[k, v]) int {
+		return b.cmp(x.item.Key, y.item.Key)
+	})
+	if kind == WAL_RECORD_ATOMIC {
+		b.applyOpsAtomically(ops)
+		return
+	}
+	b.applyOps(ops, make([]error, len(ops)))
+}
+
+func (w *wal[k, v]) runSyncer(interval time.Duration) {
+	defer close(w.syncerDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopSyncer:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.Lock()
+		if w.dirty && w.err == nil {
+			w.dirty = false
+			if err := w.file.Sync(); err != nil {
+				w.err = err
+			}
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *wal[k, v]) close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWALClosed
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	if w.stopSyncer != nil {
+		close(w.stopSyncer)
+		<-w.syncerDone
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	if syncErr := w.file.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.err = ErrWALClosed
+	return err
+}
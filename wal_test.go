@@ -0,0 +1,239 @@
+package bowl
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type intCodec struct{}
+
+func (intCodec) Encode(dst []byte, i int) []byte {
+	return binary.AppendVarint(dst, int64(i))
+}
+
+func (intCodec) Decode(src []byte) (int, error) {
+	i, read := binary.Varint(src)
+	if read != len(src) {
+		return 0, errors.New("invalid varint")
+	}
+	return int(i), nil
+}
+
+func openTestBowl(t *testing.T, path string, opts ...Option) *Bowl[int, int] {
+	t.Helper()
+	b, err := OpenBOWL[int, int](path, cmpTest, intCodec{}, intCodec{}, opts...)
+	if err != nil {
+		t.Fatalf("OpenBOWL should succeed, but instead we got %v", err)
+	}
+	return b
+}
+
+func TestOpenBOWLReplay(t *testing.T) {
+	policies := map[string]Option{
+		"always":   WithSyncPolicy(SYNC_ALWAYS, 0),
+		"interval": WithSyncPolicy(SYNC_INTERVAL, time.Millisecond),
+		"never":    WithSyncPolicy(SYNC_NEVER, 0),
+	}
+	for name, mode := range lockingModes {
+		for policyName, policy := range policies {
+			path := filepath.Join(t.TempDir(), "bowl.wal")
+			b := openTestBowl(t, path, WithLockingMode(mode), WithNodeSize(4), policy)
+
+			ihs := make([]Item[int, int], 0, 100)
+			for i := 0; i < 200; i += 2 {
+				ihs = append(ihs, Item[int, int]{Key: i, Value: i})
+			}
+			b.Insert(ihs)
+			b.Insert([]Item[int, int]{{Key: 0, Value: -1}, {Key: 1, Value: 1}})
+			b.Update([]Item[int, int]{{Key: 2, Value: -2}, {Key: 3, Value: 3}})
+			b.Delete([]int{4, 6, 8, 10})
+			b.Upsert([]Item[int, int]{{Key: 12, Value: -12}, {Key: 13, Value: 13}})
+			wb := NewWriteBatch[int, int]()
+			wb.Put(14, -14)
+			wb.Delete(16)
+			wb.Insert(17, 17)
+			b.Write(wb)
+			b.InsertAtomic([]Item[int, int]{{Key: 19, Value: 19}, {Key: 20, Value: 20}})
+			// failing, so rolled back, on replay as well
+			b.InsertAtomic([]Item[int, int]{{Key: 21, Value: 21}, {Key: 22, Value: 22}})
+			b.DeleteAtomic([]int{22, 24})
+			b.Apply([]int{26, 28, 29}, func(key int, old int, exists bool) (int, Action) {
+				if key == 28 {
+					return 0, ACTION_DELETE
+				}
+				return old + 1000, ACTION_SET
+			})
+			b.CompareAndSwap([]CASItem[int, int]{{Key: 30, Expected: 30, New: -30}, {Key: 32, Expected: 0, New: 0}},
+				func(a, b int) bool { return a == b })
+			txn := b.Begin()
+			txn.Put(34, -34)
+			txn.Delete(36)
+			txn.Delete(37)
+			txn.Commit()
+			NewCombiner(b).Insert([]Item[int, int]{{Key: 39, Value: 39}})
+			expected := snapshotContent(b)
+
+			if err := b.Close(); err != nil {
+				t.Fatalf("%s %s: Close should succeed, but instead we got %v", name, policyName, err)
+			}
+			if errs := b.Insert([]Item[int, int]{{Key: 1000, Value: 1000}}); errs[0] != ErrWALClosed {
+				t.Fatalf("%s %s: Insert after Close should fail with ErrWALClosed, but instead we got %v",
+					name, policyName, errs[0])
+			}
+			if err := b.Apply([]int{0, 1000}, func(key int, old int, exists bool) (int, Action) {
+				return 0, ACTION_DELETE
+			}); err != ErrWALClosed {
+				t.Fatalf("%s %s: Apply after Close should fail with ErrWALClosed, but instead we got %v",
+					name, policyName, err)
+			}
+			if _, err := b.CompareAndSwap([]CASItem[int, int]{{Key: 1, Expected: 1, New: -1}},
+				func(a, b int) bool { return a == b }); err != ErrWALClosed {
+				t.Fatalf("%s %s: CompareAndSwap after Close should fail with ErrWALClosed, but instead we got %v",
+					name, policyName, err)
+			}
+			if _, err := b.TryUpsert([]Item[int, int]{{Key: 1, Value: -1}}); err != ErrWALClosed {
+				t.Fatalf("%s %s: TryUpsert after Close should fail with ErrWALClosed, but instead we got %v",
+					name, policyName, err)
+			}
+			results := b.Upsert([]Item[int, int]{{Key: 1, Value: -1}, {Key: 1001, Value: 1001}})
+			if len(results) != 2 || results[0] != UPSERT_FAILED || results[1] != UPSERT_FAILED {
+				t.Fatalf("%s %s: Upsert after Close should give UPSERT_FAILED for every item, but instead we got %v",
+					name, policyName, results)
+			}
+			checkSameContent(t, name+" "+policyName+" after failed writes", expected, snapshotContent(b))
+
+			reopened := openTestBowl(t, path, WithLockingMode(mode), WithNodeSize(4), policy)
+			checkSameContent(t, name+" "+policyName+" reopened", expected, snapshotContent(reopened))
+			checkBowlLinks(t, reopened)
+
+			// and again, with the writes after replay
+			reopened.Delete([]int{0})
+			expected = snapshotContent(reopened)
+			reopened.Close()
+			reopened = openTestBowl(t, path, WithLockingMode(mode), policy)
+			checkSameContent(t, name+" "+policyName+" reopened twice", expected, snapshotContent(reopened))
+			reopened.Close()
+		}
+	}
+}
+
+func TestOpenBOWLConcurrentWriters(t *testing.T) {
+	// writers upserting the same keys, so the log should keep the order they are applied in
+	for name, mode := range lockingModes {
+		path := filepath.Join(t.TempDir(), "bowl.wal")
+		b := openTestBowl(t, path, WithLockingMode(mode), WithNodeSize(8), WithSyncPolicy(SYNC_NEVER, 0))
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for round := 0; round < 50; round++ {
+					ihs := make([]Item[int, int], 0, 100)
+					for i := 0; i < 100; i++ {
+						ihs = append(ihs, Item[int, int]{Key: i, Value: w*1000 + round})
+					}
+					b.Upsert(ihs)
+				}
+			}()
+		}
+		wg.Wait()
+		expected := snapshotContent(b)
+		b.Close()
+
+		reopened := openTestBowl(t, path, WithLockingMode(mode))
+		checkSameContent(t, name, expected, snapshotContent(reopened))
+		reopened.Close()
+	}
+}
+
+func TestOpenBOWLTruncatesTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bowl.wal")
+	b := openTestBowl(t, path)
+	b.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}})
+	expected := snapshotContent(b)
+	info, _ := os.Stat(path)
+	valid := info.Size()
+	b.Insert([]Item[int, int]{{Key: 3, Value: 3}})
+	b.Close()
+
+	for name, tear := range map[string]func(){
+		"cut in the middle": func() { os.Truncate(path, valid+WAL_HEADER_SIZE+2) },
+		"cut in the header": func() { os.Truncate(path, valid+3) },
+		"bad checksum": func() {
+			f, _ := os.OpenFile(path, os.O_RDWR, 0)
+			f.WriteAt([]byte{0xff}, valid+WAL_HEADER_SIZE+1)
+			f.Close()
+		},
+		"zero-filled tail": func() {
+			os.Truncate(path, valid)
+			os.Truncate(path, valid+4096)
+		},
+		"cut then zero-filled": func() {
+			f, _ := os.OpenFile(path, os.O_RDWR, 0)
+			f.WriteAt(make([]byte, 4096), valid+WAL_HEADER_SIZE+1)
+			f.Close()
+		},
+	} {
+		tear()
+		b = openTestBowl(t, path)
+		checkSameContent(t, name, expected, snapshotContent(b))
+		if info, _ := os.Stat(path); info.Size() != valid {
+			t.Fatalf("%s: Torn tail should be truncated to %d, but instead the size is %d", name, valid, info.Size())
+		}
+
+		// new records go right after the last valid one
+		b.Insert([]Item[int, int]{{Key: 3, Value: 3}})
+		b.Close()
+		b = openTestBowl(t, path)
+		checkSameContent(t, name+" after writing again",
+			[]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}, {Key: 3, Value: 3}}, snapshotContent(b))
+		b.Close()
+	}
+}
+
+func TestOpenBOWLCorruptedChecksumBeforeTheEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bowl.wal")
+	b := openTestBowl(t, path)
+	b.Insert([]Item[int, int]{{Key: 1, Value: 1}})
+	info, _ := os.Stat(path)
+	second := info.Size()
+	b.Insert([]Item[int, int]{{Key: 2, Value: 2}})
+	b.Insert([]Item[int, int]{{Key: 3, Value: 3}})
+	b.Close()
+	info, _ = os.Stat(path)
+	size := info.Size()
+
+	f, _ := os.OpenFile(path, os.O_RDWR, 0)
+	f.WriteAt([]byte{0xff}, second+WAL_HEADER_SIZE+1)
+	f.Close()
+
+	if _, err := OpenBOWL[int, int](path, cmpTest, intCodec{}, intCodec{}); !errors.Is(err, ErrWALCorrupted) {
+		t.Fatalf("OpenBOWL should fail with ErrWALCorrupted, but instead we got %v", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != size {
+		t.Fatalf("Records after the corrupted one should be kept, but instead the size is %d", info.Size())
+	}
+}
+
+func TestOpenBOWLCorruptedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bowl.wal")
+	// unknown record kind, with a valid checksum
+	payload := []byte{9, 0}
+	record := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+	record = binary.LittleEndian.AppendUint32(record, crc32.Checksum(payload, walCRCTable))
+	os.WriteFile(path, append(record, payload...), 0o644)
+
+	if _, err := OpenBOWL[int, int](path, cmpTest, intCodec{}, intCodec{}); !errors.Is(err, ErrWALCorrupted) {
+		t.Fatalf("OpenBOWL should fail with ErrWALCorrupted, but instead we got %v", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(record)+len(payload)) {
+		t.Fatalf("Corrupted log should be left as it is, but instead the size is %d", info.Size())
+	}
+}
@@ -0,0 +1,165 @@
+package bowl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrSupersededInBatch = errors.New("Another operation later in the same batch has the same key")
+
+// OpType is the kind of a single operation inside a WriteBatch
+type OpType int
+
+const (
+	// OP_PUT inserts or replaces, just like Upsert
+	OP_PUT    OpType = 0
+	OP_INSERT OpType = 1
+	OP_UPDATE OpType = 2
+	OP_DELETE OpType = 3
+)
+
+type batchOp[k comparable, v any] struct {
+	opType OpType
+	item   Item[k, v]
+	// index is the position of this operation, in the order it was added
+	index int
+}
+
+// WriteBatch collects mixed operations, to be applied together by `Bowl.Write`.
+// Operations can be added in any order
+//
+// A WriteBatch itself is not goroutine-safe
+type WriteBatch[k comparable, v any] struct {
+	ops []batchOp[k, v]
+}
+
+// NewWriteBatch creates an empty WriteBatch
+func NewWriteBatch[k comparable, v any]() *WriteBatch[k, v] {
+	return &WriteBatch[k, v]{}
+}
+
+// Put adds an operation to insert or replace `key`
+func (wb *WriteBatch[k, v]) Put(key k, value v) {
+	wb.add(OP_PUT, Item[k, v]{Key: key, Value: value})
+}
+
+// Insert adds an operation to insert `key`, failing if it already exists
+func (wb *WriteBatch[k, v]) Insert(key k, value v) {
+	wb.add(OP_INSERT, Item[k, v]{Key: key, Value: value})
+}
+
+// Update adds an operation to update `key`, failing if it does not exist
+func (wb *WriteBatch[k, v]) Update(key k, value v) {
+	wb.add(OP_UPDATE, Item[k, v]{Key: key, Value: value})
+}
+
+// Delete adds an operation to delete `key`, failing if it does not exist
+func (wb *WriteBatch[k, v]) Delete(key k) {
+	wb.add(OP_DELETE, Item[k, v]{Key: key})
+}
+
+// Len returns the number of operations added so far
+func (wb *WriteBatch[k, v]) Len() int {
+	return len(wb.ops)
+}
+
+func (wb *WriteBatch[k, v]) add(opType OpType, ih Item[k, v]) {
+	wb.ops = append(wb.ops, batchOp[k, v]{opType: opType, item: ih, index: len(wb.ops)})
+}
+
+// sortedOps returns the operations sorted by key. When a key has more than one operation,
+// only the last one added is kept, and the others are marked ErrSupersededInBatch in errs
+func (wb *WriteBatch[k, v]) sortedOps(cmp Comparator[k], errs []error) []batchOp[k, v] {
+	ops := slices.Clone(wb.ops)
+	slices.SortStableFunc(ops, func(a, b batchOp[k, v]) int {
+		return cmp(a.item.Key, b.item.Key)
+	})
+
+	deduped := ops[:0]
+	for i, op := range ops {
+		if i+1 < len(ops) && cmp(op.item.Key, ops[i+1].item.Key) == 0 {
+			errs[op.index] = ErrSupersededInBatch
+			continue
+		}
+		deduped = append(deduped, op)
+	}
+	return deduped
+}
+
+// Write applies all operations in wb in a single ordered pass, under a single lock,
+// so readers never see only some of them.
+// The returned errors follow the order the operations were added to wb
+//
+// When a key has more than one operation, only the last one added is applied,
+// and the earlier ones get ErrSupersededInBatch
+func (b *Bowl[k, v]) Write(wb *WriteBatch[k, v]) []error {
+	errs := make([]error, wb.Len())
+	ops := wb.sortedOps(b.cmp, errs)
+	if len(ops) == 0 {
+		return errs
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	release, err := b.logOps(WAL_RECORD_BATCH, len(ops), func(i int) batchOp[k, v] { return ops[i] })
+	if err != nil {
+		for _, op := range ops {
+			errs[op.index] = err
+		}
+		return errs
+	}
+	defer release()
+	b.structureVersion.Add(1)
+
+	b.applyOps(ops, errs)
+	return errs
+}
+
+// applyOps applies already sorted ops in a single pass, putting each result in errs[op.index].
+// Ops with the same key are applied one after another
+//
+// Should only be called when Lock is held
+func (b *Bowl[k, v]) applyOps(ops []batchOp[k, v], errs []error) {
+	b.resetLatestPointingNodes()
+	currentNode := b.getNextNodeFromHead(ops[0].item.Key)
+
+	for _, op := range ops {
+		currentNode = b.getCorrectNode(op.item.Key, currentNode)
+		currentNode, errs[op.index] = b.applyOp(currentNode, op)
+	}
+}
+
+// applyOp applies a single op into currentNode, which should already be the correct node.
+// Returns the node the cursor should continue from, as it may split
+//
+// Should only be called when Lock is held
+func (b *Bowl[k, v]) applyOp(currentNode *Node[k, v], op batchOp[k, v]) (*Node[k, v], error) {
+	var err error
+	switch op.opType {
+	case OP_PUT:
+		_, err = currentNode.Upsert(op.item)
+	case OP_INSERT:
+		err = currentNode.Insert(op.item)
+	case OP_UPDATE:
+		err = currentNode.Update(op.item)
+	case OP_DELETE:
+		err = currentNode.Delete(op.item.Key)
+		if currentNode.GetCount() == 0 {
+			currentNode.MarkRemoval()
+		}
+	}
+
+	if err != nil && err == ErrNodeIsFull {
+		currentNode = b.splitForKey(currentNode, op.item.Key)
+		if op.opType == OP_PUT {
+			_, err = currentNode.Upsert(op.item)
+		} else {
+			err = currentNode.Insert(op.item)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("Should be no error here, means something is broken: %v", err))
+		}
+	}
+	return currentNode, err
+}
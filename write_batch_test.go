@@ -0,0 +1,133 @@
+package bowl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBowlWriteBatch(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	ihs := make([]Item[int, int], 0, 1000)
+	for i := 0; i < 1000; i++ {
+		ihs = append(ihs, Item[int, int]{Key: i * 2, Value: i * 2})
+	}
+	b.Insert(ihs)
+
+	errs := b.Write(NewWriteBatch[int, int]())
+	if len(errs) != 0 {
+		t.Fatalf("Empty batch should return no errors, but instead we got %v", errs)
+	}
+
+	// added in descending order, on purpose
+	wb := NewWriteBatch[int, int]()
+	for i := 1999; i >= 0; i-- {
+		switch i % 4 {
+		case 0:
+			wb.Delete(i)
+		case 1:
+			wb.Insert(i, -i)
+		case 2:
+			wb.Update(i, -i)
+		case 3:
+			wb.Put(i, -i)
+		}
+	}
+	wb.Insert(0, 1)    // already exists, and supersedes the delete of 0
+	wb.Update(1, 1)    // does not exist, and supersedes the insert of 1
+	wb.Delete(3001)    // does not exist at all
+	wb.Insert(3000, 1) // into an empty space after everything
+
+	errs = b.Write(wb)
+	if len(errs) != wb.Len() {
+		t.Fatalf("There should be one error per operation, but instead we got %d", len(errs))
+	}
+	for j, err := range errs[:2000] {
+		i := 1999 - j
+		switch {
+		case i == 0 || i == 1:
+			if err != ErrSupersededInBatch {
+				t.Fatalf("Operation on key %d should be ErrSupersededInBatch, but instead we got %v", i, err)
+			}
+		case err != nil:
+			t.Fatalf("Operation on key %d should succeed, but instead we got %v", i, err)
+		}
+	}
+	if errs[2000] != ErrKeyAlreadyExist || errs[2001] != ErrDataNotFound ||
+		errs[2002] != ErrDataNotFound || errs[2003] != nil {
+		t.Fatalf("Last 4 operations should be ErrKeyAlreadyExist, ErrDataNotFound, ErrDataNotFound, and nil, but instead we got %v", errs[2000:])
+	}
+
+	keys := make([]int, 0, 2001)
+	for i := 0; i < 2000; i++ {
+		keys = append(keys, i)
+	}
+	keys = append(keys, 3000)
+	res := b.Get(keys, math.MinInt)
+	for i, r := range res[:2000] {
+		expected := -i
+		switch {
+		case i == 0:
+			expected = 0
+		case i == 1 || i%4 == 0:
+			expected = math.MinInt
+		}
+		if r != expected {
+			t.Fatalf("Key %d should be %d, but instead we got %d", i, expected, r)
+		}
+	}
+	if res[2000] != 1 {
+		t.Fatalf("Key 3000 should be inserted with value 1, but instead we got %d", res[2000])
+	}
+	checkBowlLinks(t, b)
+}
+
+func TestBowlWriteBatchSameKey(t *testing.T) {
+	b := NewBOWL[int, int](cmpTest)
+	if errs := b.Write(NewWriteBatch[int, int]()); len(errs) != 0 {
+		t.Fatalf("Empty batch on an empty Bowl should return no errors, but instead we got %v", errs)
+	}
+	if count := len(snapshotContent(b)); count != 0 {
+		t.Fatalf("Empty batch should write nothing, but instead we got %d data", count)
+	}
+	b.Insert([]Item[int, int]{{Key: 1, Value: 1}, {Key: 2, Value: 2}})
+
+	// only the last operation of each key is applied, whatever came before it
+	wb := NewWriteBatch[int, int]()
+	wb.Put(1, 10)
+	wb.Delete(1) // existing, put then deleted
+	wb.Delete(2)
+	wb.Put(2, 20) // existing, deleted then put
+	wb.Put(3, 30)
+	wb.Delete(3)
+	wb.Put(3, 31) // missing, put, deleted, then put again
+	wb.Delete(4)
+	wb.Put(4, 40)
+	wb.Delete(4) // missing, so the last delete fails
+	if wb.Len() != 10 {
+		t.Fatalf("Batch should have 10 operations, but instead we got %d", wb.Len())
+	}
+
+	errs := b.Write(wb)
+	expected := []error{
+		ErrSupersededInBatch, nil,
+		ErrSupersededInBatch, nil,
+		ErrSupersededInBatch, ErrSupersededInBatch, nil,
+		ErrSupersededInBatch, ErrSupersededInBatch, ErrDataNotFound,
+	}
+	for i := range expected {
+		if errs[i] != expected[i] {
+			t.Fatalf("Operation %d should be %v, but instead we got %v", i, expected[i], errs[i])
+		}
+	}
+	checkSameContent(t, "after same key operations",
+		[]Item[int, int]{{Key: 2, Value: 20}, {Key: 3, Value: 31}}, snapshotContent(b))
+
+	// the same batch can be written again, now against the new content
+	errs = b.Write(wb)
+	if errs[1] != ErrDataNotFound || errs[3] != nil || errs[6] != nil || errs[9] != ErrDataNotFound {
+		t.Fatalf("Writing the batch again should only fail deleting 1 and 4, but instead we got %v", errs)
+	}
+	if res := b.Get([]int{1, 2, 3, 4}, math.MinInt); res[0] != math.MinInt || res[1] != 20 || res[2] != 31 || res[3] != math.MinInt {
+		t.Fatalf("Keys should be missing, 20, 31, missing, but instead we got %v", res)
+	}
+}